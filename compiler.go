@@ -4,43 +4,141 @@
 package main
 
 import (
-	"bytes"
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
-	"os/exec"
-	"syscall"
-	"time"
+	"strings"
+
+	"github.com/ZiheLiu/sandbox/sandbox"
+	"github.com/docker/docker/pkg/reexec"
+)
+
+const (
+	formatText = "text"
+	formatJSON = "json"
 )
 
-// compiler wrapper with timeout limitation
-// os.Stderr will not be empty if any error occurred
+func init() {
+	// the compiler runs in a container by sandbox.Run() just like the command of clike_container, see container.go
+	if reexec.Init() {
+		os.Exit(0)
+	}
+}
+
+// compiler wrapper with time and memory limitation
+// os.Stderr will not be empty if any error occurred, with -format=json os.Stdout carries a sandbox.CompileResult instead
 func main() {
-	compiler := flag.String("compiler", "/usr/bin/gcc", "C/CPP compiler with abs path")
-	basedir := flag.String("basedir", "/tmp", "basedir of tmp C/CPP code snippet")
-	filename := flag.String("filename", "Main.c", "name of file to be compiled")
-	timeout := flag.Int("timeout", 5000, "compile timeout in milliseconds")
-	std := flag.String("std", "gnu11", "language standards supported by gcc")
+	defaults := sandbox.DefaultCompileConfig()
+	lang := flag.String("lang", defaults.Lang, "language profile: c, cpp, java, go, rust, pascal or one of -lang-profiles")
+	langProfiles := flag.String("lang-profiles", "", "YAML or JSON file of additional language profiles")
+	compiler := flag.String("compiler", defaults.Compiler, "compiler with abs path, the one of -lang if empty")
+	basedir := flag.String("basedir", defaults.BaseDir, "basedir of tmp code snippet")
+	filename := flag.String("filename", defaults.Filename, "name of file to be compiled, the one of -lang if empty")
+	timeout := flag.Int("timeout", int(defaults.Timeout), "compile timeout in milliseconds, the one of -lang if 0")
+	std := flag.String("std", defaults.Std, "language standards supported by the compiler, the one of -lang if empty")
+	memory := flag.Int64("memory", defaults.Memory, "memory limit of the compiler in KB, the one of -lang if 0")
+	fileSize := flag.Int64("file-size", defaults.FileSize, "max size in KB of every file written by the compiler, 64MB if 0")
+	outputLimit := flag.Int64("output-limit", defaults.OutputLimit, "max size in KB of stdout and of stderr of the compiler, 256KB if 0")
+	username := flag.String("username", defaults.Username, "the user compiler runs as")
+	sources := flag.String("sources", "", "comma separated names of the other files of the submission in -basedir, compiled with -filename")
+	includeDirs := flag.String("include-dirs", "", "comma separated directories of headers below -problem-dir, e.g. of a grader, searched first")
+	link := flag.String("link", "", "comma separated files below -problem-dir compiled or linked with the submission, e.g. grader.c or grader.o")
+	problemDir := flag.String("problem-dir", "", "directory of the files of the problems read as -username, none if empty")
+	var flags stringsFlag
+	flag.Var(&flags, "flag", "flag of the compiler allowed by the language profile, e.g. -O2 or -lm, may be repeated")
+	forbiddenHeaders := flag.String("forbidden-headers", "", "comma separated headers the submission must not include, e.g. bits/stdc++.h")
+	cacheDir := flag.String("cache-dir", "", "directory of the compile cache reusing identical compilations, no cache if empty")
+	cacheSize := flag.Int64("cache-size", 1024, "size of -cache-dir in MB, the least recently used compilations are evicted beyond")
+	noCache := flag.Bool("no-cache", false, "compile even if -cache-dir holds the compilation, which is replaced")
+	format := flag.String("format", formatText, "format of the output, text or json with the parsed diagnostics")
 	flag.Parse()
 
-	var stdout, stderr bytes.Buffer
-	cmd := exec.Command(*compiler, *filename, "-save-temps", "-std="+*std, "-fmax-errors=10", "-static", "-o", "Main")
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Setpgid: true,
+	// os.Stderr only carries the diagnostics of the compiler, it must stay empty on success
+	sandbox.SetLogOutput(ioutil.Discard)
+
+	if *format != formatText && *format != formatJSON {
+		_, _ = os.Stderr.WriteString(fmt.Sprintf("unknown format: %s\n", *format))
+		return
+	}
+	if *langProfiles != "" {
+		if err := sandbox.LoadLanguageProfiles(*langProfiles); err != nil {
+			writeCompileResult(*format, &sandbox.CompileResult{Diagnostics: []sandbox.Diagnostic{}, Error: err.Error()})
+			return
+		}
 	}
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-	cmd.Dir = *basedir
 
-	time.AfterFunc(time.Duration(*timeout)*time.Millisecond, func() {
-		_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
-	})
+	cfg := &sandbox.CompileConfig{
+		Lang:        *lang,
+		Compiler:    *compiler,
+		BaseDir:     *basedir,
+		Filename:    *filename,
+		Std:         *std,
+		Timeout:     int64(*timeout),
+		Memory:      *memory,
+		FileSize:    *fileSize,
+		OutputLimit: *outputLimit,
+		Username:    *username,
+		Toolchain:   defaults.Toolchain,
+		NoCache:     *noCache,
 
-	if err := cmd.Run(); err != nil {
-		// err.Error() == "signal: killed" means compiler is killed by our timer.
-		_, _ = os.Stderr.WriteString(fmt.Sprintf("stderr: %s, err: %s\n", stderr.String(), err.Error()))
-		return
+		Sources:          splitList(*sources),
+		IncludeDirs:      splitList(*includeDirs),
+		Link:             splitList(*link),
+		Flags:            flags,
+		ForbiddenHeaders: splitList(*forbiddenHeaders),
 	}
+	if *problemDir != "" {
+		dir, err := sandbox.NewHostDir(*problemDir)
+		if err != nil {
+			writeCompileResult(*format, &sandbox.CompileResult{Diagnostics: []sandbox.Diagnostic{}, Error: err.Error()})
+			return
+		}
+		cfg.ProblemDir = dir
+	}
+	if *cacheDir != "" {
+		cache, err := sandbox.NewCompileCache(*cacheDir, *cacheSize*1024*1024)
+		if err != nil {
+			writeCompileResult(*format, &sandbox.CompileResult{Diagnostics: []sandbox.Diagnostic{}, Error: err.Error()})
+			return
+		}
+		cfg.Cache = cache
+	}
+	writeCompileResult(*format, sandbox.CompileWithResult(context.Background(), cfg))
+}
 
+// stringsFlag is a flag which may be repeated, e.g. -flag=-O2 -flag=-lm
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, " ")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// splitList splits a comma separated flag, nil if empty.
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+// writeCompileResult writes r as a single line of JSON to os.Stdout, or as text, i.e. the error to os.Stderr.
+func writeCompileResult(format string, r *sandbox.CompileResult) {
+	if format == formatJSON {
+		b, _ := json.Marshal(r)
+		_, _ = os.Stdout.Write(append(b, '\n'))
+		return
+	}
+	if !r.OK {
+		_, _ = os.Stderr.WriteString(fmt.Sprintf("%s\n", r.Error))
+		return
+	}
 	_, _ = os.Stdout.WriteString("Compile OK\n")
 }
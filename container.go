@@ -4,14 +4,17 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
-	uuid "github.com/satori/go.uuid"
+	"io"
 	"io/ioutil"
 	"os"
-	"os/exec"
 	"os/user"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
@@ -21,16 +24,21 @@ import (
 	"github.com/docker/docker/pkg/reexec"
 )
 
-func init() {
-	// register "justiceInit" => justiceInit() every time
-	reexec.Register("justiceInit", justiceInit)
+const (
+	resultText = "text"
+	resultJSON = "json"
+)
+
+// stdout limitation in KB of a command whose output is judged outside -batch, which keeps it in memory
+const judgedStdoutLimit = 64 * 1024
 
+func init() {
 	/**
-	* 0. `init()` adds key "justiceInit" in `map`;
+	* 0. package sandbox adds keys "justiceInit" and "justiceExec" in `map` in its `init()`;
 	* 1. reexec.Init() seeks if key `os.Args[0]` exists in `registeredInitializers`;
 	* 2. for the first time this binary is invoked, the key is os.Args[0], AKA "/path/to/clike_container",
 	     which `registeredInitializers` will return `false`;
-	* 3. `main()` calls binary itself by reexec.Command("justiceInit", args...);
+	* 3. `sandbox.Run()` calls binary itself by reexec.Command("justiceInit", args...);
 	* 4. for the second time this binary is invoked, the key is os.Args[0], AKA "justiceInit",
 	*    which exists in `registeredInitializers`;
 	* 5. the value `justiceInit()` is invoked, any hooks(like set hostname) before fork() can be placed here.
@@ -40,135 +48,469 @@ func init() {
 	}
 }
 
-func justiceInit() {
-	basedir := os.Args[1]
-	command := os.Args[2]
-	timeout, _ := strconv.ParseInt(os.Args[3], 10, 32)
+// stringsFlag is a flag which may be repeated, e.g. -arg=a -arg=b
+type stringsFlag []string
 
-	if err := sandbox.InitNamespace(basedir); err != nil {
-		_, _ = os.Stderr.WriteString(fmt.Sprintf("%s\n", err.Error()))
-		os.Exit(0)
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, " ")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// commandEnv returns env, and before it the variables of the host named by allow which env does not set.
+func commandEnv(env []string, allow string) []string {
+	set := make(map[string]bool)
+	for _, e := range env {
+		set[strings.SplitN(e, "=", 2)[0]] = true
 	}
+	var host []string
+	for _, name := range strings.Split(allow, ",") {
+		if value, ok := os.LookupEnv(name); ok && name != "" && !set[name] {
+			host = append(host, name+"="+value)
+		}
+	}
+	return append(host, env...)
+}
 
-	cmd := exec.Command(command)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Setpgid:    true,
-		Credential: &syscall.Credential{Uid: uint32(1), Gid: uint32(1)},
+// batchCase is one test case of -batch, the command reads Input and writes Output, and Error unless it is empty.
+// Output is compared with Expected by -check.
+type batchCase struct {
+	Name     string `json:"name"`
+	Input    string `json:"input"`
+	Output   string `json:"output"`
+	Error    string `json:"error"`
+	Expected string `json:"expected"`
+}
+
+// loadBatch lists the cases of batch, either a directory of `<name>.in` and `<name>.ans` files sorted by name
+// or a json manifest of cases.
+// Relative paths of a manifest are relative to the manifest, outputs default to `<name>.out` in outputDir.
+func loadBatch(batch, outputDir string) ([]*batchCase, error) {
+	info, err := os.Stat(batch)
+	if err != nil {
+		return nil, err
 	}
-	cmd.Env = []string{"PS1=[justice] # "}
 
-	tle := false
-	time.AfterFunc(time.Duration(timeout)*time.Millisecond, func() {
-		tle = true
-		_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
-	})
+	var cases []*batchCase
+	if info.IsDir() {
+		if outputDir == "" {
+			return nil, fmt.Errorf("-batch-output is required with a directory of inputs")
+		}
+		inputs, err := filepath.Glob(filepath.Join(batch, "*.in"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(inputs)
+		for _, input := range inputs {
+			name := strings.TrimSuffix(filepath.Base(input), ".in")
+			cases = append(cases, &batchCase{
+				Name:     name,
+				Input:    input,
+				Output:   filepath.Join(outputDir, name+".out"),
+				Error:    filepath.Join(outputDir, name+".err"),
+				Expected: strings.TrimSuffix(input, ".in") + ".ans",
+			})
+		}
+	} else {
+		c, err := ioutil.ReadFile(batch)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(c, &cases); err != nil {
+			return nil, fmt.Errorf("invalid batch manifest %s: %s", batch, err.Error())
+		}
+		resolve := func(path string) string {
+			if path == "" || filepath.IsAbs(path) {
+				return path
+			}
+			return filepath.Join(filepath.Dir(batch), path)
+		}
+		for _, c := range cases {
+			if c.Name == "" || c.Input == "" {
+				return nil, fmt.Errorf("invalid batch manifest %s: every case needs a name and an input", batch)
+			}
+			if c.Output == "" {
+				if outputDir == "" {
+					return nil, fmt.Errorf("invalid batch manifest %s: no output for case %s", batch, c.Name)
+				}
+				c.Output = filepath.Join(outputDir, c.Name+".out")
+			}
+			c.Input, c.Output, c.Error, c.Expected = resolve(c.Input), resolve(c.Output), resolve(c.Error), resolve(c.Expected)
+		}
+	}
 
-	startTime := time.Now().UnixNano() / 1e6
-	if err := cmd.Run(); err != nil {
-		if tle {
-			_, _ = os.Stderr.WriteString(fmt.Sprintln("Time Limit Error"))
-		} else {
-			_, _ = os.Stderr.WriteString(fmt.Sprintf("%s\n", err.Error()))
+	if len(cases) == 0 {
+		return nil, fmt.Errorf("no test cases in %s", batch)
+	}
+	return cases, nil
+}
+
+// runCase runs cfg in the sandbox of pool with the files of c as stdin, stdout and stderr, then judges the output
+// unless judge is nil. With interaction, c has no output and Expected is optional, and the command and the
+// interactor get fresh containers.
+func runCase(cfg *sandbox.Config, pool *sandbox.Pool, judge sandbox.Judge, interaction *sandbox.Interaction, c *batchCase) *sandbox.Result {
+	result := func() *sandbox.Result {
+		stdin, err := os.Open(c.Input)
+		if err != nil {
+			return &sandbox.Result{Verdict: sandbox.VerdictSystemError, Error: err.Error()}
 		}
-		os.Exit(0)
+		defer stdin.Close()
+
+		var stderr io.Writer = ioutil.Discard
+		if c.Error != "" {
+			f, err := os.Create(c.Error)
+			if err != nil {
+				return &sandbox.Result{Verdict: sandbox.VerdictSystemError, Error: err.Error()}
+			}
+			defer f.Close()
+			stderr = f
+		}
+
+		if interaction != nil {
+			var expected io.Reader = strings.NewReader("")
+			if f, err := os.Open(c.Expected); err == nil {
+				defer f.Close()
+				expected = f
+			}
+			return interaction.Run(cfg, stdin, expected, stderr)
+		}
+
+		stdout, err := os.Create(c.Output)
+		if err != nil {
+			return &sandbox.Result{Verdict: sandbox.VerdictSystemError, Error: err.Error()}
+		}
+		defer stdout.Close()
+
+		result := pool.Run(context.Background(), cfg, stdin, stdout, stderr)
+		if judge != nil {
+			judgeFiles(judge, result, c.Input, c.Output, c.Expected)
+		}
+		return result
+	}()
+	result.Case = c.Name
+	return result
+}
+
+// judgeFiles judges result by the files input, output and expected.
+func judgeFiles(judge sandbox.Judge, result *sandbox.Result, input, output, expected string) {
+	if result.Verdict != sandbox.VerdictOK {
+		return
+	}
+	var files []io.Reader
+	for _, name := range []string{input, output, expected} {
+		f, err := os.Open(name)
+		if err != nil {
+			result.Verdict, result.Error = sandbox.VerdictSystemError, err.Error()
+			return
+		}
+		defer f.Close()
+		files = append(files, f)
 	}
-	endTime := time.Now().UnixNano() / 1e6
+	judge.Judge(result, files[0], files[1], files[2])
+}
 
-	timeCost, memoryCost := endTime-startTime, cmd.ProcessState.SysUsage().(*syscall.Rusage).Maxrss/1024
-	_, _ = os.Stderr.WriteString(fmt.Sprintf("INFO: timeCost:%v\n", timeCost))
-	_, _ = os.Stderr.WriteString(fmt.Sprintf("INFO: memoryCost:%v\n", memoryCost))
+// legacy text of the verdicts set by a sandbox.Judge
+var judgedVerdicts = map[sandbox.Verdict]string{
+	sandbox.VerdictAccepted:          "Accepted",
+	sandbox.VerdictWrongAnswer:       "Wrong Answer",
+	sandbox.VerdictPresentationError: "Presentation Error",
 }
 
-func cgroupOomControl(containerId string) map[string]string {
-	res := make(map[string]string)
+// writeResult reports result to w as the legacy INFO/error lines or as a JSON record.
+func writeResult(format string, w io.Writer, result *sandbox.Result) {
+	if format == resultJSON {
+		_, _ = w.Write(sandbox.MarshalResult(result))
+		return
+	}
 
-	filePath := filepath.Join("/sys/fs/cgroup/memory/", containerId, "memory.oom_control")
-	c, _ := ioutil.ReadFile(filePath)
-	rows := strings.Split(string(c), "\n")
-	for _, row := range rows {
-		if row != "" {
-			params := strings.Split(row, " ")
-			res[params[0]] = params[1]
+	if result.Case != "" {
+		_, _ = fmt.Fprintf(w, "INFO: case:%s\n", result.Case)
+	}
+	switch result.Verdict {
+	case sandbox.VerdictOK, sandbox.VerdictAccepted, sandbox.VerdictWrongAnswer, sandbox.VerdictPresentationError:
+		_, _ = fmt.Fprintf(w, "INFO: timeCost:%v\n", result.WallTime)
+		_, _ = fmt.Fprintf(w, "INFO: memoryCost:%v\n", result.Memory/1024)
+		verdict := judgedVerdicts[result.Verdict]
+		switch {
+		case result.Difference != nil:
+			d := result.Difference
+			_, _ = fmt.Fprintf(w, "%s at line %d, column %d: expected %q, got %q\n", verdict, d.Line, d.Column, d.Expected, d.Actual)
+		case result.Message != "":
+			_, _ = fmt.Fprintf(w, "%s: %s\n", verdict, result.Message)
+		case verdict != "":
+			_, _ = fmt.Fprintln(w, verdict)
 		}
+	case sandbox.VerdictTimeLimitExceeded:
+		_, _ = fmt.Fprintln(w, "Time Limit Error")
+	case sandbox.VerdictMemoryLimitExceeded:
+		_, _ = fmt.Fprintln(w, "Memory Limit Error")
+	case sandbox.VerdictRestrictedFunction:
+		_, _ = fmt.Fprintln(w, "Restricted Function")
+	case sandbox.VerdictOutputLimitExceeded:
+		_, _ = fmt.Fprintln(w, "Output Limit Error")
+	case sandbox.VerdictIdleLimitExceeded:
+		_, _ = fmt.Fprintln(w, "Idle Limit Error")
+	default:
+		_, _ = fmt.Fprintf(w, "%s\n", result.Error)
 	}
+}
 
-	return res
+// openResult opens the destination of the json result, either the file name or the inherited fd.
+func openResult(name string, fd int) (*os.File, error) {
+	switch {
+	case name != "" && fd != 0:
+		return nil, fmt.Errorf("-result-file and -result-fd are exclusive")
+	case name != "":
+		return os.Create(name)
+	case fd > syscall.Stderr:
+		// the command must not inherit it
+		syscall.CloseOnExec(fd)
+		f := os.NewFile(uintptr(fd), "result")
+		if _, err := f.Stat(); err != nil {
+			return nil, fmt.Errorf("invalid -result-fd %d: %s", fd, err.Error())
+		}
+		return f, nil
+	case fd != 0:
+		return nil, fmt.Errorf("invalid -result-fd %d, stdin, stdout and stderr belong to the command", fd)
+	}
+	return nil, fmt.Errorf("-result=json needs -result-file or -result-fd")
 }
 
-// logs will be printed to os.Stderr
+// logs will be printed to os.Stderr, with -result=json they are discarded and the result record goes to -result-file or -result-fd
 func main() {
 	basedir := flag.String("basedir", "/tmp", "basedir of tmp binary")
 	command := flag.String("command", "./Main", "the command needed to be execute in sandbox")
 	timeout := flag.String("timeout", "2000", "timeout in milliseconds")
 	memory := flag.String("memory", "256", "memory limitation in KB")
 	username := flag.String("username", "root", "the user to execute command")
-	cpus := flag.String("cpus", "0", "the user to execute command")
+	cpus := flag.String("cpus", "0", "cpus to execute command on, e.g. 0 or 0-3")
+	defaults := sandbox.DefaultLimits()
+	pids := flag.Int64("pids", defaults.Pids, "max number of processes and threads")
+	cpuQuota := flag.Int64("cpu-quota", defaults.CPUQuota, "cpu time in microseconds per cpu-period, -1 for no limit")
+	cpuPeriod := flag.Int64("cpu-period", defaults.CPUPeriod, "cpu bandwidth period in microseconds")
+	kmemory := flag.Int64("kmemory", defaults.KMemory, "kernel memory limitation in KB, -1 for no limit, cgroup v1 before linux 6.1 only")
+	swap := flag.Int64("swap", defaults.Swap, "swap limitation in KB on top of memory, -1 for no limit")
+	cpuTime := flag.Int64("cpu-time", 0, "cpu time limit in milliseconds of all processes and threads, 0 for timeout only")
+	stdoutLimit := flag.Int64("stdout-limit", 0, "stdout limitation in KB, 0 for no limit, 65536 if judged by -check or -checker without -batch")
+	stderrLimit := flag.Int64("stderr-limit", 0, "stderr limitation in KB, 0 for no limit")
+	fileSize := flag.Int64("file-size", 0, "max size in KB of files written by the command, 0 for no limit")
+	format := flag.String("result", resultText, "format of the result, text or json")
+	resultFile := flag.String("result-file", "", "file to write the json result to, see -result-fd")
+	resultFd := flag.Int("result-fd", 0, "fd inherited from the caller to write the json result to, e.g. 3, -result=json needs it or -result-file")
+	seccomp := flag.String("seccomp", "", "seccomp profile, c, cpp, a json file or none, the one of -lang if empty, c without -lang")
+	batch := flag.String("batch", "", "directory of <name>.in inputs or json manifest of test cases to run the command against one by one")
+	batchOutput := flag.String("batch-output", "", "directory to write <name>.out and <name>.err of every test case to")
+	stopOnFailure := flag.Bool("stop-on-failure", false, "stop -batch at the first test case which is neither OK nor AC")
+	check := flag.String("check", "", "compare the output with the expected output, exact, lines, tokens or float, no check if empty")
+	expected := flag.String("expected", "", "file of the expected output to judge stdout against or answer of -interactor, <name>.ans of every case with -batch")
+	absEpsilon := flag.Float64("abs-eps", 1e-6, "absolute error allowed by -check=float")
+	relEpsilon := flag.Float64("rel-eps", 1e-6, "relative error allowed by -check=float")
+	checkerPath := flag.String("checker", "", "testlib style checker binary judging the output instead of -check")
+	checkerTimeout := flag.Int64("checker-timeout", 10000, "timeout of -checker in milliseconds")
+	checkerMemory := flag.Int64("checker-memory", 262144, "memory limitation of -checker in KB")
+	interactor := flag.String("interactor", "", "testlib style interactor binary the command talks to through stdin and stdout")
+	interactorTimeout := flag.Int64("interactor-timeout", 10000, "timeout of -interactor in milliseconds")
+	interactorMemory := flag.Int64("interactor-memory", 262144, "memory limitation of -interactor in KB")
+	idleLimit := flag.Int64("idle-limit", 1000, "milliseconds the command and -interactor may both be blocked, 0 for no limit")
+	lang := flag.String("lang", "", "language profile running the submission instead of -command, e.g. python or java, see clike_compiler")
+	langProfiles := flag.String("lang-profiles", "", "YAML or JSON file of additional language profiles")
+	var args, env stringsFlag
+	flag.Var(&args, "arg", "argument of the command, may be repeated")
+	flag.Var(&env, "env", "environment variable key=value of the command, may be repeated")
+	envAllow := flag.String("env-allow", "", "comma separated names of environment variables passed through from the host, e.g. LANG,TZ")
 	flag.Parse()
 
-	containerId := uuid.NewV4().String()
-	if err := sandbox.InitCGroup(strconv.Itoa(os.Getpid()), containerId, *memory, *cpus); err != nil {
-		_, _ = os.Stderr.WriteString(fmt.Sprintf("%s\n", err.Error()))
+	if *format != resultText && *format != resultJSON {
+		_, _ = os.Stderr.WriteString(fmt.Sprintf("unknown result format: %s\n", *format))
+		os.Exit(2)
+	}
+	if *format == resultJSON {
+		sandbox.SetLogOutput(ioutil.Discard)
+	}
+
+	// the json result never shares stderr with the command, which could print a record of its own
+	var resultWriter io.Writer = os.Stderr
+	if *format == resultJSON {
+		f, err := openResult(*resultFile, *resultFd)
+		if err != nil {
+			_, _ = os.Stderr.WriteString(fmt.Sprintf("%s\n", err.Error()))
+			os.Exit(0)
+		}
+		defer f.Close()
+		resultWriter = f
+	}
+
+	systemError := func(err error) {
+		writeResult(*format, resultWriter, &sandbox.Result{Verdict: sandbox.VerdictSystemError, Error: err.Error()})
 		os.Exit(0)
 	}
 
 	u, err := user.Lookup(*username)
 	if err != nil {
-		_, _ = os.Stderr.WriteString(fmt.Sprintf("%s\n", err.Error()))
-		os.Exit(0)
+		systemError(err)
 	}
 	uid, _ := strconv.Atoi(u.Uid)
 	gid, _ := strconv.Atoi(u.Gid)
 
-	cmd := reexec.Command("justiceInit", *basedir, *command, *timeout)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Cloneflags: syscall.CLONE_NEWNS |
-			syscall.CLONE_NEWUTS |
-			syscall.CLONE_NEWIPC |
-			syscall.CLONE_NEWPID |
-			syscall.CLONE_NEWNET |
-			syscall.CLONE_NEWUSER,
-		UidMappings: []syscall.SysProcIDMap{
-			{
-				ContainerID: 0,
-				HostID:      os.Getuid(),
-				Size:        1,
-			},
-			{
-				ContainerID: 1,
-				HostID:      uid,
-				Size:        1,
-			},
-		},
-		GidMappings: []syscall.SysProcIDMap{
-			{
-				ContainerID: 0,
-				HostID:      os.Getgid(),
-				Size:        1,
-			},
-			{
-				ContainerID: 1,
-				HostID:      gid,
-				Size:        1,
-			},
+	memoryLimit, err := strconv.ParseInt(*memory, 10, 64)
+	if err != nil {
+		systemError(err)
+	}
+	timeLimit, err := strconv.ParseInt(*timeout, 10, 64)
+	if err != nil {
+		systemError(err)
+	}
+
+	var profile *sandbox.LanguageProfile
+	if *langProfiles != "" {
+		if err := sandbox.LoadLanguageProfiles(*langProfiles); err != nil {
+			systemError(err)
+		}
+	}
+	if *lang != "" {
+		if profile, err = sandbox.LookupLanguageProfile(*lang); err != nil {
+			systemError(err)
+		}
+	}
+	if *seccomp == "" {
+		if *seccomp, err = sandbox.DefaultSeccompProfile(*lang); err != nil {
+			systemError(err)
+		}
+	}
+
+	cfg := &sandbox.Config{
+		BaseDir: *basedir,
+		Command: *command,
+		Args:    args,
+		Env:     commandEnv(env, *envAllow),
+		Timeout: timeLimit,
+		CPUTime: *cpuTime,
+		Limits: &sandbox.Limits{
+			Memory:    memoryLimit,
+			CPUs:      *cpus,
+			Pids:      *pids,
+			CPUQuota:  *cpuQuota,
+			CPUPeriod: *cpuPeriod,
+			KMemory:   *kmemory,
+			Swap:      *swap,
 		},
-		GidMappingsEnableSetgroups: true,
+		Seccomp:     *seccomp,
+		StdoutLimit: *stdoutLimit,
+		StderrLimit: *stderrLimit,
+		FileSize:    *fileSize,
+		UID:         uid,
+		GID:         gid,
+		Quiet:       *format == resultJSON,
 	}
 
-	if err := cmd.Run(); err != nil {
-		oomInfo := cgroupOomControl(containerId)
-		if val, ok := oomInfo["oom_kill"]; ok && val != "0" {
-			_, _ = os.Stderr.WriteString(fmt.Sprintln("Memory Limit Error"))
-		} else {
-			_, _ = os.Stderr.WriteString(fmt.Sprintf("%s\n", err.Error()))
+	// the checker and the interactor get a sandbox of their own, with the limits of the command but for time and memory
+	programCfg := func(timeout, memory int64) *sandbox.Config {
+		programCfg := *cfg
+		programLimits := *cfg.Limits
+		programLimits.Memory = memory
+		programCfg.Limits, programCfg.Timeout, programCfg.CPUTime, programCfg.Quiet = &programLimits, timeout, 0, true
+		programCfg.Env = nil
+		return &programCfg
+	}
+
+	judges := 0
+	for _, judge := range []string{*check, *checkerPath, *interactor} {
+		if judge != "" {
+			judges++
 		}
-		os.Exit(0)
+	}
+	if judges > 1 {
+		systemError(fmt.Errorf("-check, -checker and -interactor are exclusive"))
 	}
 
-	os.Exit(0)
+	var judge sandbox.Judge
+	var interaction *sandbox.Interaction
+	switch {
+	case *check != "":
+		if judge, err = sandbox.NewChecker(sandbox.CheckMode(*check), *absEpsilon, *relEpsilon); err != nil {
+			systemError(err)
+		}
+	case *checkerPath != "":
+		judge = &sandbox.SpecialJudge{Checker: *checkerPath, Config: programCfg(*checkerTimeout, *checkerMemory)}
+	case *interactor != "":
+		interaction = &sandbox.Interaction{
+			Interactor: *interactor,
+			Config:     programCfg(*interactorTimeout, *interactorMemory),
+			IdleLimit:  time.Duration(*idleLimit) * time.Millisecond,
+		}
+	}
+
+	// the checker and the interactor above are run as they are, only the command by the runtime of the language
+	if profile != nil {
+		cfg = profile.RunConfig(cfg)
+	}
+
+	if *batch == "" && interaction != nil {
+		input, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			systemError(err)
+		}
+		var expectedOutput io.Reader = strings.NewReader("")
+		if *expected != "" {
+			expectedFile, err := os.Open(*expected)
+			if err != nil {
+				systemError(err)
+			}
+			defer expectedFile.Close()
+			expectedOutput = expectedFile
+		}
+		writeResult(*format, resultWriter, interaction.Run(cfg, bytes.NewReader(input), expectedOutput, os.Stderr))
+		return
+	}
+
+	if *batch == "" {
+		if judge == nil {
+			writeResult(*format, resultWriter, sandbox.Run(cfg, os.Stdin, os.Stdout, os.Stderr))
+			return
+		}
+		expectedFile, err := os.Open(*expected)
+		if err != nil {
+			systemError(err)
+		}
+		defer expectedFile.Close()
+
+		// the judge needs the whole input, even if the command does not read it
+		input, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			systemError(err)
+		}
+		// stdout is still passed through, a copy is kept for the judge
+		if cfg.StdoutLimit == 0 {
+			cfg.StdoutLimit = judgedStdoutLimit
+		}
+		var output bytes.Buffer
+		result := sandbox.Run(cfg, bytes.NewReader(input), io.MultiWriter(os.Stdout, &output), os.Stderr)
+		judge.Judge(result, bytes.NewReader(input), &output, expectedFile)
+		writeResult(*format, resultWriter, result)
+		return
+	}
+
+	cases, err := loadBatch(*batch, *batchOutput)
+	if err != nil {
+		systemError(err)
+	}
+	// the cases run one after the other in a single container, whose cgroup is reset and limited again in between,
+	// nothing is carried over but the binary
+	var pool *sandbox.Pool
+	if interaction == nil {
+		if pool, err = sandbox.NewPool(1, uid, gid); err != nil {
+			systemError(err)
+		}
+		defer pool.Close()
+	}
+	for _, c := range cases {
+		result := runCase(cfg, pool, judge, interaction, c)
+		writeResult(*format, resultWriter, result)
+		if *stopOnFailure && result.Verdict != sandbox.VerdictOK && result.Verdict != sandbox.VerdictAccepted {
+			break
+		}
+	}
 }
@@ -0,0 +1,577 @@
+// +build linux
+// +build go1.12
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"os/user"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ZiheLiu/sandbox/judge"
+	"github.com/ZiheLiu/sandbox/sandbox"
+	"github.com/docker/docker/pkg/reexec"
+	uuid "github.com/satori/go.uuid"
+	"google.golang.org/grpc"
+)
+
+const (
+	jobCompile = "compile"
+	jobRun     = "run"
+
+	statusQueued  = "queued"
+	statusRunning = "running"
+	statusDone    = "done"
+)
+
+func init() {
+	// the daemon runs containers by sandbox.Run() just like clike_container, see container.go
+	if reexec.Init() {
+		os.Exit(0)
+	}
+}
+
+// compileRequest is the body of POST /compile, fields default to the flags of clike_compiler, see
+// sandbox.CompileConfig. The compiler and its toolchain are those of the language profile and the user is the one of
+// the daemon, BaseDir is relative to or below the work root, IncludeDirs and Link to or below the problem root.
+type compileRequest struct {
+	Lang             string   `json:"lang"`
+	BaseDir          string   `json:"basedir"`
+	Filename         string   `json:"filename"`
+	Std              string   `json:"std"`
+	Timeout          int64    `json:"timeout"`
+	Memory           int64    `json:"memory"`
+	FileSize         int64    `json:"fileSize"`
+	OutputLimit      int64    `json:"outputLimit"`
+	Sources          []string `json:"sources"`
+	IncludeDirs      []string `json:"includeDirs"`
+	Link             []string `json:"link"`
+	Flags            []string `json:"flags"`
+	ForbiddenHeaders []string `json:"forbiddenHeaders"`
+	NoCache          bool     `json:"noCache"`
+}
+
+// runRequest is the body of POST /run, fields default to the flags of clike_container and the user is the one of
+// the daemon. BaseDir, Stdin, Stdout and Stderr are relative to or below the work root, empty for none but BaseDir,
+// which is the work root if empty.
+type runRequest struct {
+	BaseDir string          `json:"basedir"`
+	Command string          `json:"command"`
+	Timeout int64           `json:"timeout"`
+	CPUTime int64           `json:"cpuTime"`
+	Limits  *sandbox.Limits `json:"limits"`
+	// a built-in profile, none with -allow-seccomp-none or a file below -seccomp-root, the one of Lang if empty
+	Seccomp     string `json:"seccomp"`
+	StdoutLimit int64  `json:"stdoutLimit"`
+	StderrLimit int64  `json:"stderrLimit"`
+	FileSize    int64  `json:"fileSize"`
+	// language profile running the submission instead of Command, see clike_container -lang
+	Lang   string   `json:"lang"`
+	Args   []string `json:"args"`
+	Env    []string `json:"env"`
+	Stdin  string   `json:"stdin"`
+	Stdout string   `json:"stdout"`
+	Stderr string   `json:"stderr"`
+}
+
+// job is a compilation or a run, its id is the id of the container and cgroup of a run.
+type job struct {
+	ID     string          `json:"id"`
+	Kind   string          `json:"kind"`
+	Status string          `json:"status"`
+	Result *sandbox.Result `json:"result,omitempty"`
+	// diagnostics and resource usage of a compilation
+	Compile *sandbox.CompileResult `json:"compile,omitempty"`
+	// compilation error, or failure of the daemon
+	Error string `json:"error,omitempty"`
+
+	execute  func(ctx context.Context, j *job)
+	cg       *sandbox.CGroup
+	finished time.Time
+}
+
+// daemon executes jobs submitted over HTTP by a bounded pool of workers.
+type daemon struct {
+	mutex sync.Mutex
+	jobs  map[string]*job
+	queue chan *job
+	// holds an element per job executing, shared with the JudgeService so that both together run at most -workers
+	slots chan struct{}
+	ttl   time.Duration
+	// nil without -compile-cache-dir
+	cache *sandbox.CompileCache
+	// the runs are leased a sandbox of the pool, which executes commands as the user of the daemon, nil without -pool
+	pool *sandbox.Pool
+	// the paths of the requests are below it
+	workRoot sandbox.HostDir
+	// the include dirs and files to link of compilations are below it, none are allowed if empty
+	problemRoot sandbox.HostDir
+	// the seccomp profiles runs may choose
+	seccomp sandbox.SeccompPolicy
+	// the host user compilations and runs execute as, never root
+	username string
+	uid, gid int
+
+	// done once the daemon shuts down, which kills the sandboxes in flight
+	ctx     context.Context
+	cancel  context.CancelFunc
+	workers sync.WaitGroup
+}
+
+func newDaemon(slots chan struct{}, queue int, ttl time.Duration, cache *sandbox.CompileCache, pool *sandbox.Pool,
+	workRoot, problemRoot sandbox.HostDir, seccomp sandbox.SeccompPolicy, username string, uid, gid int) *daemon {
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &daemon{
+		jobs:        make(map[string]*job),
+		queue:       make(chan *job, queue),
+		slots:       slots,
+		ttl:         ttl,
+		cache:       cache,
+		pool:        pool,
+		workRoot:    workRoot,
+		problemRoot: problemRoot,
+		seccomp:     seccomp,
+		username:    username,
+		uid:         uid,
+		gid:         gid,
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+	d.workers.Add(cap(slots))
+	for i := 0; i < cap(slots); i++ {
+		go d.work()
+	}
+	go d.expire()
+	return d
+}
+
+func (d *daemon) work() {
+	defer d.workers.Done()
+	for j := range d.queue {
+		if d.ctx.Err() != nil {
+			d.finish(j, nil, "daemon is shutting down")
+			continue
+		}
+		// a compilation or a run of the JudgeService may hold the slots
+		select {
+		case <-d.ctx.Done():
+			d.finish(j, nil, "daemon is shutting down")
+			continue
+		case d.slots <- struct{}{}:
+		}
+		d.mutex.Lock()
+		j.Status = statusRunning
+		d.mutex.Unlock()
+		j.execute(d.ctx, j)
+		<-d.slots
+	}
+}
+
+// finish records the outcome of j.
+func (d *daemon) finish(j *job, result *sandbox.Result, err string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	j.Status, j.Result, j.Error, j.cg, j.finished = statusDone, result, err, nil, time.Now()
+}
+
+// expire forgets jobs which are done for longer than ttl.
+func (d *daemon) expire() {
+	ticker := time.NewTicker(d.ttl / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-ticker.C:
+			d.mutex.Lock()
+			for id, j := range d.jobs {
+				if j.Status == statusDone && time.Since(j.finished) > d.ttl {
+					delete(d.jobs, id)
+				}
+			}
+			d.mutex.Unlock()
+		}
+	}
+}
+
+// submit queues j, or fails if the queue is full or the daemon shuts down.
+func (d *daemon) submit(j *job) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.ctx.Err() != nil {
+		return fmt.Errorf("daemon is shutting down")
+	}
+	select {
+	case d.queue <- j:
+		d.jobs[j.ID] = j
+		return nil
+	default:
+		return fmt.Errorf("queue is full")
+	}
+}
+
+// shutdown stops accepting jobs, kills the sandboxes in flight and waits for the workers.
+func (d *daemon) shutdown() {
+	d.mutex.Lock()
+	d.cancel()
+	close(d.queue)
+	for _, j := range d.jobs {
+		if j.cg != nil {
+			_ = j.cg.Kill()
+		}
+	}
+	d.mutex.Unlock()
+	d.workers.Wait()
+	if d.pool != nil {
+		d.pool.Close()
+	}
+}
+
+func (d *daemon) compile(ctx context.Context, j *job, cfg *sandbox.CompileConfig) {
+	r := sandbox.CompileWithResult(ctx, cfg)
+	d.mutex.Lock()
+	j.Compile = r
+	d.mutex.Unlock()
+	d.finish(j, nil, r.Error)
+}
+
+func (d *daemon) run(j *job, cfg *sandbox.Config, req *runRequest) {
+	var stdin io.Reader
+	var stdout, stderr io.Writer = ioutil.Discard, ioutil.Discard
+	if req.Stdin != "" {
+		f, err := d.workRoot.Open(req.Stdin)
+		if err != nil {
+			d.finish(j, nil, err.Error())
+			return
+		}
+		defer f.Close()
+		stdin = f
+	}
+	if req.Stdout != "" {
+		f, err := d.workRoot.Create(req.Stdout)
+		if err != nil {
+			d.finish(j, nil, err.Error())
+			return
+		}
+		defer f.Close()
+		stdout = f
+	}
+	if req.Stderr != "" {
+		f, err := d.workRoot.Create(req.Stderr)
+		if err != nil {
+			d.finish(j, nil, err.Error())
+			return
+		}
+		defer f.Close()
+		stderr = f
+	}
+
+	cfg.Started = func(cg *sandbox.CGroup) {
+		d.mutex.Lock()
+		defer d.mutex.Unlock()
+		j.cg = cg
+		// the daemon started shutting down right before the command was started
+		if d.ctx.Err() != nil {
+			_ = cg.Kill()
+		}
+	}
+	if d.pool != nil {
+		d.finish(j, d.pool.Run(d.ctx, cfg, stdin, stdout, stderr), "")
+		return
+	}
+	d.finish(j, sandbox.Run(cfg, stdin, stdout, stderr), "")
+}
+
+// ServeHTTP serves
+// POST /compile with a compileRequest, POST /run with a runRequest, both answering the queued job,
+// and GET /results/<id> answering the job. Requests with unknown fields, e.g. a compiler or a user, are rejected.
+func (d *daemon) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	reply := func(status int, v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(v)
+	}
+	fail := func(status int, err error) {
+		reply(status, map[string]string{"error": err.Error()})
+	}
+	decode := func(v interface{}) error {
+		decoder := json.NewDecoder(r.Body)
+		decoder.DisallowUnknownFields()
+		return decoder.Decode(v)
+	}
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/compile":
+		req := &compileRequest{}
+		if err := decode(req); err != nil {
+			fail(http.StatusBadRequest, err)
+			return
+		}
+		cfg, err := d.compileConfig(req)
+		if err != nil {
+			fail(http.StatusBadRequest, err)
+			return
+		}
+		j := &job{ID: uuid.NewV4().String(), Kind: jobCompile, Status: statusQueued}
+		j.execute = func(ctx context.Context, j *job) {
+			d.compile(ctx, j, cfg)
+		}
+		d.accept(w, j, reply, fail)
+	case r.Method == http.MethodPost && r.URL.Path == "/run":
+		req := &runRequest{
+			Command: "./Main",
+			Timeout: 2000,
+			Limits:  sandbox.DefaultLimits(),
+		}
+		if err := decode(req); err != nil {
+			fail(http.StatusBadRequest, err)
+			return
+		}
+		var err error
+		if req.BaseDir, err = d.workRoot.Resolve(req.BaseDir); err != nil {
+			fail(http.StatusBadRequest, err)
+			return
+		}
+		// the files are opened by the worker, their paths are checked before the job is queued
+		for _, name := range []*string{&req.Stdin, &req.Stdout, &req.Stderr} {
+			if *name == "" {
+				continue
+			}
+			if *name, err = d.workRoot.Resolve(*name); err != nil {
+				fail(http.StatusBadRequest, err)
+				return
+			}
+		}
+		if req.Seccomp, err = d.seccomp.Resolve(req.Seccomp); err != nil {
+			fail(http.StatusBadRequest, err)
+			return
+		}
+		if req.Seccomp == "" {
+			if req.Seccomp, err = sandbox.DefaultSeccompProfile(req.Lang); err != nil {
+				fail(http.StatusBadRequest, err)
+				return
+			}
+		}
+		j := &job{ID: uuid.NewV4().String(), Kind: jobRun, Status: statusQueued}
+		cfg := &sandbox.Config{
+			ID:          j.ID,
+			BaseDir:     req.BaseDir,
+			Command:     req.Command,
+			Args:        req.Args,
+			Env:         req.Env,
+			Timeout:     req.Timeout,
+			CPUTime:     req.CPUTime,
+			Limits:      req.Limits,
+			Seccomp:     req.Seccomp,
+			StdoutLimit: req.StdoutLimit,
+			StderrLimit: req.StderrLimit,
+			FileSize:    req.FileSize,
+			UID:         d.uid,
+			GID:         d.gid,
+			Quiet:       true,
+		}
+		if err := cfg.Validate(); err != nil {
+			fail(http.StatusBadRequest, err)
+			return
+		}
+		if req.Lang != "" {
+			profile, err := sandbox.LookupLanguageProfile(req.Lang)
+			if err != nil {
+				fail(http.StatusBadRequest, err)
+				return
+			}
+			cfg = profile.RunConfig(cfg)
+		}
+		j.execute = func(_ context.Context, j *job) {
+			d.run(j, cfg, req)
+		}
+		d.accept(w, j, reply, fail)
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/results/"):
+		d.mutex.Lock()
+		j, ok := d.jobs[strings.TrimPrefix(r.URL.Path, "/results/")]
+		var snapshot job
+		if ok {
+			snapshot = *j
+		}
+		d.mutex.Unlock()
+		if !ok {
+			fail(http.StatusNotFound, fmt.Errorf("no such job"))
+			return
+		}
+		reply(http.StatusOK, &snapshot)
+	default:
+		fail(http.StatusNotFound, fmt.Errorf("no such endpoint: %s %s", r.Method, r.URL.Path))
+	}
+}
+
+// compileConfig fills the zero fields of req with the defaults of clike_compiler and resolves its basedir below the
+// work root, see sandbox.CompileConfig.ProblemDir for the others.
+func (d *daemon) compileConfig(req *compileRequest) (*sandbox.CompileConfig, error) {
+	cfg := sandbox.DefaultCompileConfig()
+	cfg.Username, cfg.Cache = d.username, d.cache
+	if req.Lang != "" {
+		cfg.Lang = req.Lang
+	}
+	cfg.Filename, cfg.Std, cfg.Timeout, cfg.Memory = req.Filename, req.Std, req.Timeout, req.Memory
+	cfg.FileSize, cfg.OutputLimit, cfg.NoCache = req.FileSize, req.OutputLimit, req.NoCache
+	cfg.Sources, cfg.Flags, cfg.ForbiddenHeaders = req.Sources, req.Flags, req.ForbiddenHeaders
+	cfg.IncludeDirs, cfg.Link, cfg.ProblemDir = req.IncludeDirs, req.Link, d.problemRoot
+
+	var err error
+	if cfg.BaseDir, err = d.workRoot.Resolve(req.BaseDir); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (d *daemon) accept(w http.ResponseWriter, j *job, reply func(int, interface{}), fail func(int, error)) {
+	if err := d.submit(j); err != nil {
+		fail(http.StatusServiceUnavailable, err)
+		return
+	}
+	reply(http.StatusAccepted, &job{ID: j.ID, Kind: j.Kind, Status: statusQueued})
+}
+
+// judge daemon serving the compiler and the container over HTTP, see daemon.ServeHTTP, and optionally gRPC
+func main() {
+	listen := flag.String("listen", "127.0.0.1:8080", "tcp address to listen on")
+	socket := flag.String("socket", "", "unix socket to listen on instead of -listen")
+	workers := flag.Int("workers", runtime.NumCPU(), "number of jobs and JudgeService requests executed at the same time")
+	queue := flag.Int("queue", 64, "number of jobs waiting for a worker, more are rejected")
+	ttl := flag.Duration("result-ttl", 10*time.Minute, "how long results are kept once the job is done")
+	grpcListen := flag.String("grpc-listen", "", "tcp address to serve the JudgeService of package judge on, none if empty")
+	grpcSocket := flag.String("grpc-socket", "", "unix socket to serve the JudgeService on instead of -grpc-listen")
+	langProfiles := flag.String("lang-profiles", "", "YAML or JSON file of additional language profiles of the compiler")
+	cacheDir := flag.String("compile-cache-dir", "", "directory of the compile cache, see clike_compiler -cache-dir, no cache if empty")
+	cacheSize := flag.Int64("compile-cache-size", 1024, "size of -compile-cache-dir in MB")
+	workRoot := flag.String("work-root", "", "directory the paths of the requests are relative to or below, required")
+	problemRoot := flag.String("problem-root", "", "directory of the files of the problems, the include dirs and files to link of compilations are relative to or below it, none if empty")
+	seccompRoot := flag.String("seccomp-root", "", "directory of the json seccomp profiles runs may choose, only the built-in profiles if empty")
+	allowSeccompNone := flag.Bool("allow-seccomp-none", false, "whether runs may turn syscall filtering off by the seccomp profile none")
+	username := flag.String("username", "nobody", "the user compilations and runs execute as, must not be root")
+	poolSize := flag.Int("pool", 0, "number of pre-warmed sandboxes of -username leased by the runs, none if 0")
+	flag.Parse()
+
+	if *workers <= 0 || *queue < 0 || *ttl < time.Second {
+		_, _ = os.Stderr.WriteString(fmt.Sprintf("invalid workers %d, queue %d or result-ttl %s\n", *workers, *queue, *ttl))
+		os.Exit(1)
+	}
+	root, err := sandbox.NewHostDir(*workRoot)
+	if err != nil {
+		_, _ = os.Stderr.WriteString(fmt.Sprintf("invalid -work-root, err: %s\n", err.Error()))
+		os.Exit(1)
+	}
+	var problems sandbox.HostDir
+	if *problemRoot != "" {
+		if problems, err = sandbox.NewHostDir(*problemRoot); err != nil {
+			_, _ = os.Stderr.WriteString(fmt.Sprintf("invalid -problem-root, err: %s\n", err.Error()))
+			os.Exit(1)
+		}
+	}
+	seccomp := sandbox.SeccompPolicy{AllowNone: *allowSeccompNone}
+	if *seccompRoot != "" {
+		if seccomp.Dir, err = sandbox.NewHostDir(*seccompRoot); err != nil {
+			_, _ = os.Stderr.WriteString(fmt.Sprintf("invalid -seccomp-root, err: %s\n", err.Error()))
+			os.Exit(1)
+		}
+	}
+	u, err := user.Lookup(*username)
+	if err != nil {
+		_, _ = os.Stderr.WriteString(fmt.Sprintf("%s\n", err.Error()))
+		os.Exit(1)
+	}
+	uid, _ := strconv.Atoi(u.Uid)
+	gid, _ := strconv.Atoi(u.Gid)
+	if uid == 0 || gid == 0 {
+		_, _ = os.Stderr.WriteString(fmt.Sprintf("invalid user %s, must not be root\n", *username))
+		os.Exit(1)
+	}
+	if *langProfiles != "" {
+		if err := sandbox.LoadLanguageProfiles(*langProfiles); err != nil {
+			_, _ = os.Stderr.WriteString(fmt.Sprintf("%s\n", err.Error()))
+			os.Exit(1)
+		}
+	}
+
+	var cache *sandbox.CompileCache
+	if *cacheDir != "" {
+		if cache, err = sandbox.NewCompileCache(*cacheDir, *cacheSize*1024*1024); err != nil {
+			_, _ = os.Stderr.WriteString(fmt.Sprintf("%s\n", err.Error()))
+			os.Exit(1)
+		}
+	}
+
+	listenOn := func(address, socket string) net.Listener {
+		var listener net.Listener
+		var err error
+		if socket != "" {
+			_ = os.Remove(socket)
+			listener, err = net.Listen("unix", socket)
+		} else {
+			listener, err = net.Listen("tcp", address)
+		}
+		if err != nil {
+			_, _ = os.Stderr.WriteString(fmt.Sprintf("%s\n", err.Error()))
+			os.Exit(1)
+		}
+		return listener
+	}
+	listener := listenOn(*listen, *socket)
+
+	var pool *sandbox.Pool
+	if *poolSize > 0 {
+		if pool, err = sandbox.NewPool(*poolSize, uid, gid); err != nil {
+			_, _ = os.Stderr.WriteString(fmt.Sprintf("%s\n", err.Error()))
+			os.Exit(1)
+		}
+	}
+
+	// the JudgeService answers synchronously rather than queueing jobs, it shares the workers of the jobs
+	slots := make(chan struct{}, *workers)
+	judgeServer, err := judge.NewServer(slots, cache, root, problems, seccomp, *username)
+	if err != nil {
+		_, _ = os.Stderr.WriteString(fmt.Sprintf("%s\n", err.Error()))
+		os.Exit(1)
+	}
+	d := newDaemon(slots, *queue, *ttl, cache, pool, root, problems, seccomp, *username, uid, gid)
+	server := &http.Server{Handler: d}
+	grpcServer := grpc.NewServer()
+	judge.RegisterJudgeServiceServer(grpcServer, judgeServer)
+	if *grpcListen != "" || *grpcSocket != "" {
+		grpcListener := listenOn(*grpcListen, *grpcSocket)
+		go func() {
+			_ = grpcServer.Serve(grpcListener)
+		}()
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-signals
+		// stop accepting requests first, then kill what is in flight
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(ctx)
+		grpcServer.Stop()
+	}()
+
+	if err := server.Serve(listener); err != http.ErrServerClosed {
+		_, _ = os.Stderr.WriteString(fmt.Sprintf("%s\n", err.Error()))
+	}
+	d.shutdown()
+	judgeServer.Shutdown()
+}
@@ -0,0 +1,1525 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: judge/judge.proto
+
+// JudgeService compiles and runs submissions in the sandbox, the typed counterpart of
+// clike_compiler, clike_container and the HTTP API of clike_daemon.
+//
+// Regenerate judge.pb.go with protoc-gen-go v1.3.x:
+//   protoc --go_out=plugins=grpc,paths=source_relative:. judge/judge.proto
+
+package judge
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+// the verdicts of sandbox.Result
+type Verdict int32
+
+const (
+	Verdict_UNKNOWN Verdict = 0
+	Verdict_OK      Verdict = 1
+	Verdict_TLE     Verdict = 2
+	Verdict_MLE     Verdict = 3
+	Verdict_RE      Verdict = 4
+	Verdict_OLE     Verdict = 5
+	Verdict_RF      Verdict = 6
+	Verdict_SE      Verdict = 7
+	Verdict_AC      Verdict = 8
+	Verdict_WA      Verdict = 9
+	Verdict_PE      Verdict = 10
+	Verdict_ILE     Verdict = 11
+	// of a compilation
+	Verdict_CE  Verdict = 12
+	Verdict_CLE Verdict = 13
+)
+
+var Verdict_name = map[int32]string{
+	0:  "UNKNOWN",
+	1:  "OK",
+	2:  "TLE",
+	3:  "MLE",
+	4:  "RE",
+	5:  "OLE",
+	6:  "RF",
+	7:  "SE",
+	8:  "AC",
+	9:  "WA",
+	10: "PE",
+	11: "ILE",
+	12: "CE",
+	13: "CLE",
+}
+
+var Verdict_value = map[string]int32{
+	"UNKNOWN": 0,
+	"OK":      1,
+	"TLE":     2,
+	"MLE":     3,
+	"RE":      4,
+	"OLE":     5,
+	"RF":      6,
+	"SE":      7,
+	"AC":      8,
+	"WA":      9,
+	"PE":      10,
+	"ILE":     11,
+	"CE":      12,
+	"CLE":     13,
+}
+
+func (x Verdict) String() string {
+	return proto.EnumName(Verdict_name, int32(x))
+}
+
+func (Verdict) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_6ba88695d5965b00, []int{0}
+}
+
+// zero fields default to the flags of clike_compiler, the compiler and its user are those of the daemon,
+// basedir is relative to or below the work root of the daemon
+type CompileRequest struct {
+	Basedir  string `protobuf:"bytes,2,opt,name=basedir,proto3" json:"basedir,omitempty"`
+	Filename string `protobuf:"bytes,3,opt,name=filename,proto3" json:"filename,omitempty"`
+	Std      string `protobuf:"bytes,4,opt,name=std,proto3" json:"std,omitempty"`
+	// milliseconds
+	Timeout int64 `protobuf:"varint,5,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	// name of the language profile of the compiler, c if empty
+	Lang string `protobuf:"bytes,6,opt,name=lang,proto3" json:"lang,omitempty"`
+	// compile even if the compile cache of the daemon holds the compilation
+	NoCache bool `protobuf:"varint,7,opt,name=no_cache,json=noCache,proto3" json:"no_cache,omitempty"`
+	// the other files of a multi-file submission in basedir, compiled with filename
+	Sources []string `protobuf:"bytes,8,rep,name=sources,proto3" json:"sources,omitempty"`
+	// directories of headers searched first, and files compiled or linked with the submission, relative to or below
+	// the problem root of the daemon
+	IncludeDirs []string `protobuf:"bytes,9,rep,name=include_dirs,json=includeDirs,proto3" json:"include_dirs,omitempty"`
+	Link        []string `protobuf:"bytes,10,rep,name=link,proto3" json:"link,omitempty"`
+	// headers the submission must not include, e.g. bits/stdc++.h
+	ForbiddenHeaders []string `protobuf:"bytes,11,rep,name=forbidden_headers,json=forbiddenHeaders,proto3" json:"forbidden_headers,omitempty"`
+	// flags of the compiler allowed by its language profile, e.g. -O2 or -lm
+	Flags []string `protobuf:"bytes,12,rep,name=flags,proto3" json:"flags,omitempty"`
+	// KB, the memory of the compiler, the max size of every file it writes and of its stdout and stderr
+	Memory               int64    `protobuf:"varint,13,opt,name=memory,proto3" json:"memory,omitempty"`
+	FileSize             int64    `protobuf:"varint,14,opt,name=file_size,json=fileSize,proto3" json:"file_size,omitempty"`
+	OutputLimit          int64    `protobuf:"varint,15,opt,name=output_limit,json=outputLimit,proto3" json:"output_limit,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CompileRequest) Reset()         { *m = CompileRequest{} }
+func (m *CompileRequest) String() string { return proto.CompactTextString(m) }
+func (*CompileRequest) ProtoMessage()    {}
+func (*CompileRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6ba88695d5965b00, []int{0}
+}
+
+func (m *CompileRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CompileRequest.Unmarshal(m, b)
+}
+func (m *CompileRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CompileRequest.Marshal(b, m, deterministic)
+}
+func (m *CompileRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CompileRequest.Merge(m, src)
+}
+func (m *CompileRequest) XXX_Size() int {
+	return xxx_messageInfo_CompileRequest.Size(m)
+}
+func (m *CompileRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CompileRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CompileRequest proto.InternalMessageInfo
+
+func (m *CompileRequest) GetBasedir() string {
+	if m != nil {
+		return m.Basedir
+	}
+	return ""
+}
+
+func (m *CompileRequest) GetFilename() string {
+	if m != nil {
+		return m.Filename
+	}
+	return ""
+}
+
+func (m *CompileRequest) GetStd() string {
+	if m != nil {
+		return m.Std
+	}
+	return ""
+}
+
+func (m *CompileRequest) GetTimeout() int64 {
+	if m != nil {
+		return m.Timeout
+	}
+	return 0
+}
+
+func (m *CompileRequest) GetLang() string {
+	if m != nil {
+		return m.Lang
+	}
+	return ""
+}
+
+func (m *CompileRequest) GetNoCache() bool {
+	if m != nil {
+		return m.NoCache
+	}
+	return false
+}
+
+func (m *CompileRequest) GetSources() []string {
+	if m != nil {
+		return m.Sources
+	}
+	return nil
+}
+
+func (m *CompileRequest) GetIncludeDirs() []string {
+	if m != nil {
+		return m.IncludeDirs
+	}
+	return nil
+}
+
+func (m *CompileRequest) GetLink() []string {
+	if m != nil {
+		return m.Link
+	}
+	return nil
+}
+
+func (m *CompileRequest) GetForbiddenHeaders() []string {
+	if m != nil {
+		return m.ForbiddenHeaders
+	}
+	return nil
+}
+
+func (m *CompileRequest) GetFlags() []string {
+	if m != nil {
+		return m.Flags
+	}
+	return nil
+}
+
+func (m *CompileRequest) GetMemory() int64 {
+	if m != nil {
+		return m.Memory
+	}
+	return 0
+}
+
+func (m *CompileRequest) GetFileSize() int64 {
+	if m != nil {
+		return m.FileSize
+	}
+	return 0
+}
+
+func (m *CompileRequest) GetOutputLimit() int64 {
+	if m != nil {
+		return m.OutputLimit
+	}
+	return 0
+}
+
+type CompileResponse struct {
+	Ok bool `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	// stderr of the compiler unless ok
+	Error    string `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	ExitCode int32  `protobuf:"varint,3,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
+	// the time limit killed the compiler
+	Timeout bool `protobuf:"varint,4,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	// milliseconds and KB
+	CpuTime     int64         `protobuf:"varint,5,opt,name=cpu_time,json=cpuTime,proto3" json:"cpu_time,omitempty"`
+	WallTime    int64         `protobuf:"varint,6,opt,name=wall_time,json=wallTime,proto3" json:"wall_time,omitempty"`
+	Memory      int64         `protobuf:"varint,7,opt,name=memory,proto3" json:"memory,omitempty"`
+	Diagnostics []*Diagnostic `protobuf:"bytes,8,rep,name=diagnostics,proto3" json:"diagnostics,omitempty"`
+	// taken from the compile cache of the daemon rather than compiled
+	Cached bool `protobuf:"varint,9,opt,name=cached,proto3" json:"cached,omitempty"`
+	// OK, CE, CLE, or SE if the compiler did not run
+	Verdict Verdict `protobuf:"varint,10,opt,name=verdict,proto3,enum=judge.Verdict" json:"verdict,omitempty"`
+	// time, memory, output or fileSize with CLE
+	Limit                string   `protobuf:"bytes,11,opt,name=limit,proto3" json:"limit,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CompileResponse) Reset()         { *m = CompileResponse{} }
+func (m *CompileResponse) String() string { return proto.CompactTextString(m) }
+func (*CompileResponse) ProtoMessage()    {}
+func (*CompileResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6ba88695d5965b00, []int{1}
+}
+
+func (m *CompileResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CompileResponse.Unmarshal(m, b)
+}
+func (m *CompileResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CompileResponse.Marshal(b, m, deterministic)
+}
+func (m *CompileResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CompileResponse.Merge(m, src)
+}
+func (m *CompileResponse) XXX_Size() int {
+	return xxx_messageInfo_CompileResponse.Size(m)
+}
+func (m *CompileResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_CompileResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CompileResponse proto.InternalMessageInfo
+
+func (m *CompileResponse) GetOk() bool {
+	if m != nil {
+		return m.Ok
+	}
+	return false
+}
+
+func (m *CompileResponse) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *CompileResponse) GetExitCode() int32 {
+	if m != nil {
+		return m.ExitCode
+	}
+	return 0
+}
+
+func (m *CompileResponse) GetTimeout() bool {
+	if m != nil {
+		return m.Timeout
+	}
+	return false
+}
+
+func (m *CompileResponse) GetCpuTime() int64 {
+	if m != nil {
+		return m.CpuTime
+	}
+	return 0
+}
+
+func (m *CompileResponse) GetWallTime() int64 {
+	if m != nil {
+		return m.WallTime
+	}
+	return 0
+}
+
+func (m *CompileResponse) GetMemory() int64 {
+	if m != nil {
+		return m.Memory
+	}
+	return 0
+}
+
+func (m *CompileResponse) GetDiagnostics() []*Diagnostic {
+	if m != nil {
+		return m.Diagnostics
+	}
+	return nil
+}
+
+func (m *CompileResponse) GetCached() bool {
+	if m != nil {
+		return m.Cached
+	}
+	return false
+}
+
+func (m *CompileResponse) GetVerdict() Verdict {
+	if m != nil {
+		return m.Verdict
+	}
+	return Verdict_UNKNOWN
+}
+
+func (m *CompileResponse) GetLimit() string {
+	if m != nil {
+		return m.Limit
+	}
+	return ""
+}
+
+// a message of the compiler, line and column start at 1 and are 0 if unknown
+type Diagnostic struct {
+	File   string `protobuf:"bytes,1,opt,name=file,proto3" json:"file,omitempty"`
+	Line   int32  `protobuf:"varint,2,opt,name=line,proto3" json:"line,omitempty"`
+	Column int32  `protobuf:"varint,3,opt,name=column,proto3" json:"column,omitempty"`
+	// error, warning or note
+	Severity             string   `protobuf:"bytes,4,opt,name=severity,proto3" json:"severity,omitempty"`
+	Message              string   `protobuf:"bytes,5,opt,name=message,proto3" json:"message,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Diagnostic) Reset()         { *m = Diagnostic{} }
+func (m *Diagnostic) String() string { return proto.CompactTextString(m) }
+func (*Diagnostic) ProtoMessage()    {}
+func (*Diagnostic) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6ba88695d5965b00, []int{2}
+}
+
+func (m *Diagnostic) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Diagnostic.Unmarshal(m, b)
+}
+func (m *Diagnostic) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Diagnostic.Marshal(b, m, deterministic)
+}
+func (m *Diagnostic) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Diagnostic.Merge(m, src)
+}
+func (m *Diagnostic) XXX_Size() int {
+	return xxx_messageInfo_Diagnostic.Size(m)
+}
+func (m *Diagnostic) XXX_DiscardUnknown() {
+	xxx_messageInfo_Diagnostic.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Diagnostic proto.InternalMessageInfo
+
+func (m *Diagnostic) GetFile() string {
+	if m != nil {
+		return m.File
+	}
+	return ""
+}
+
+func (m *Diagnostic) GetLine() int32 {
+	if m != nil {
+		return m.Line
+	}
+	return 0
+}
+
+func (m *Diagnostic) GetColumn() int32 {
+	if m != nil {
+		return m.Column
+	}
+	return 0
+}
+
+func (m *Diagnostic) GetSeverity() string {
+	if m != nil {
+		return m.Severity
+	}
+	return ""
+}
+
+func (m *Diagnostic) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+// zero fields default to the flags of clike_container, times in milliseconds and sizes in KB,
+// the user is the one of the daemon
+type Limits struct {
+	Timeout int64  `protobuf:"varint,1,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	Memory  int64  `protobuf:"varint,2,opt,name=memory,proto3" json:"memory,omitempty"`
+	Cpus    string `protobuf:"bytes,3,opt,name=cpus,proto3" json:"cpus,omitempty"`
+	CpuTime int64  `protobuf:"varint,5,opt,name=cpu_time,json=cpuTime,proto3" json:"cpu_time,omitempty"`
+	Pids    int64  `protobuf:"varint,6,opt,name=pids,proto3" json:"pids,omitempty"`
+	// -1 for no limit
+	CpuQuota  int64 `protobuf:"varint,7,opt,name=cpu_quota,json=cpuQuota,proto3" json:"cpu_quota,omitempty"`
+	CpuPeriod int64 `protobuf:"varint,8,opt,name=cpu_period,json=cpuPeriod,proto3" json:"cpu_period,omitempty"`
+	// -1 for no limit
+	Kmemory int64 `protobuf:"varint,9,opt,name=kmemory,proto3" json:"kmemory,omitempty"`
+	// on top of memory, no limit if 0 or -1
+	Swap int64 `protobuf:"varint,10,opt,name=swap,proto3" json:"swap,omitempty"`
+	// 1024 if 0, stdout and stderr are sent back whole
+	StdoutLimit int64 `protobuf:"varint,11,opt,name=stdout_limit,json=stdoutLimit,proto3" json:"stdout_limit,omitempty"`
+	StderrLimit int64 `protobuf:"varint,12,opt,name=stderr_limit,json=stderrLimit,proto3" json:"stderr_limit,omitempty"`
+	FileSize    int64 `protobuf:"varint,13,opt,name=file_size,json=fileSize,proto3" json:"file_size,omitempty"`
+	// the one of the language if empty, see sandbox.DefaultSeccompProfile, else a built-in profile, or none and a file
+	// below the seccomp root if the daemon allows them, see clike_daemon -allow-seccomp-none and -seccomp-root
+	Seccomp              string   `protobuf:"bytes,14,opt,name=seccomp,proto3" json:"seccomp,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Limits) Reset()         { *m = Limits{} }
+func (m *Limits) String() string { return proto.CompactTextString(m) }
+func (*Limits) ProtoMessage()    {}
+func (*Limits) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6ba88695d5965b00, []int{3}
+}
+
+func (m *Limits) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Limits.Unmarshal(m, b)
+}
+func (m *Limits) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Limits.Marshal(b, m, deterministic)
+}
+func (m *Limits) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Limits.Merge(m, src)
+}
+func (m *Limits) XXX_Size() int {
+	return xxx_messageInfo_Limits.Size(m)
+}
+func (m *Limits) XXX_DiscardUnknown() {
+	xxx_messageInfo_Limits.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Limits proto.InternalMessageInfo
+
+func (m *Limits) GetTimeout() int64 {
+	if m != nil {
+		return m.Timeout
+	}
+	return 0
+}
+
+func (m *Limits) GetMemory() int64 {
+	if m != nil {
+		return m.Memory
+	}
+	return 0
+}
+
+func (m *Limits) GetCpus() string {
+	if m != nil {
+		return m.Cpus
+	}
+	return ""
+}
+
+func (m *Limits) GetCpuTime() int64 {
+	if m != nil {
+		return m.CpuTime
+	}
+	return 0
+}
+
+func (m *Limits) GetPids() int64 {
+	if m != nil {
+		return m.Pids
+	}
+	return 0
+}
+
+func (m *Limits) GetCpuQuota() int64 {
+	if m != nil {
+		return m.CpuQuota
+	}
+	return 0
+}
+
+func (m *Limits) GetCpuPeriod() int64 {
+	if m != nil {
+		return m.CpuPeriod
+	}
+	return 0
+}
+
+func (m *Limits) GetKmemory() int64 {
+	if m != nil {
+		return m.Kmemory
+	}
+	return 0
+}
+
+func (m *Limits) GetSwap() int64 {
+	if m != nil {
+		return m.Swap
+	}
+	return 0
+}
+
+func (m *Limits) GetStdoutLimit() int64 {
+	if m != nil {
+		return m.StdoutLimit
+	}
+	return 0
+}
+
+func (m *Limits) GetStderrLimit() int64 {
+	if m != nil {
+		return m.StderrLimit
+	}
+	return 0
+}
+
+func (m *Limits) GetFileSize() int64 {
+	if m != nil {
+		return m.FileSize
+	}
+	return 0
+}
+
+func (m *Limits) GetSeccomp() string {
+	if m != nil {
+		return m.Seccomp
+	}
+	return ""
+}
+
+// Check compares stdout with the expected output of the test case, see sandbox.Checker
+type Check struct {
+	// exact, lines, tokens or float
+	Mode                 string   `protobuf:"bytes,1,opt,name=mode,proto3" json:"mode,omitempty"`
+	AbsEps               float64  `protobuf:"fixed64,2,opt,name=abs_eps,json=absEps,proto3" json:"abs_eps,omitempty"`
+	RelEps               float64  `protobuf:"fixed64,3,opt,name=rel_eps,json=relEps,proto3" json:"rel_eps,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Check) Reset()         { *m = Check{} }
+func (m *Check) String() string { return proto.CompactTextString(m) }
+func (*Check) ProtoMessage()    {}
+func (*Check) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6ba88695d5965b00, []int{4}
+}
+
+func (m *Check) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Check.Unmarshal(m, b)
+}
+func (m *Check) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Check.Marshal(b, m, deterministic)
+}
+func (m *Check) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Check.Merge(m, src)
+}
+func (m *Check) XXX_Size() int {
+	return xxx_messageInfo_Check.Size(m)
+}
+func (m *Check) XXX_DiscardUnknown() {
+	xxx_messageInfo_Check.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Check proto.InternalMessageInfo
+
+func (m *Check) GetMode() string {
+	if m != nil {
+		return m.Mode
+	}
+	return ""
+}
+
+func (m *Check) GetAbsEps() float64 {
+	if m != nil {
+		return m.AbsEps
+	}
+	return 0
+}
+
+func (m *Check) GetRelEps() float64 {
+	if m != nil {
+		return m.RelEps
+	}
+	return 0
+}
+
+type TestCase struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Input                []byte   `protobuf:"bytes,2,opt,name=input,proto3" json:"input,omitempty"`
+	Expected             []byte   `protobuf:"bytes,3,opt,name=expected,proto3" json:"expected,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TestCase) Reset()         { *m = TestCase{} }
+func (m *TestCase) String() string { return proto.CompactTextString(m) }
+func (*TestCase) ProtoMessage()    {}
+func (*TestCase) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6ba88695d5965b00, []int{5}
+}
+
+func (m *TestCase) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TestCase.Unmarshal(m, b)
+}
+func (m *TestCase) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TestCase.Marshal(b, m, deterministic)
+}
+func (m *TestCase) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TestCase.Merge(m, src)
+}
+func (m *TestCase) XXX_Size() int {
+	return xxx_messageInfo_TestCase.Size(m)
+}
+func (m *TestCase) XXX_DiscardUnknown() {
+	xxx_messageInfo_TestCase.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TestCase proto.InternalMessageInfo
+
+func (m *TestCase) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *TestCase) GetInput() []byte {
+	if m != nil {
+		return m.Input
+	}
+	return nil
+}
+
+func (m *TestCase) GetExpected() []byte {
+	if m != nil {
+		return m.Expected
+	}
+	return nil
+}
+
+type RunRequest struct {
+	// relative to or below the work root of the daemon, the work root if empty
+	Basedir string `protobuf:"bytes,1,opt,name=basedir,proto3" json:"basedir,omitempty"`
+	// ./Main if empty
+	Command  string    `protobuf:"bytes,2,opt,name=command,proto3" json:"command,omitempty"`
+	Limits   *Limits   `protobuf:"bytes,3,opt,name=limits,proto3" json:"limits,omitempty"`
+	TestCase *TestCase `protobuf:"bytes,4,opt,name=test_case,json=testCase,proto3" json:"test_case,omitempty"`
+	// no check if empty
+	Check *Check `protobuf:"bytes,5,opt,name=check,proto3" json:"check,omitempty"`
+	// language profile running the submission instead of command, see clike_container -lang
+	Lang string `protobuf:"bytes,6,opt,name=lang,proto3" json:"lang,omitempty"`
+	// arguments and environment variables key=value of the command
+	Args                 []string `protobuf:"bytes,7,rep,name=args,proto3" json:"args,omitempty"`
+	Env                  []string `protobuf:"bytes,8,rep,name=env,proto3" json:"env,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RunRequest) Reset()         { *m = RunRequest{} }
+func (m *RunRequest) String() string { return proto.CompactTextString(m) }
+func (*RunRequest) ProtoMessage()    {}
+func (*RunRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6ba88695d5965b00, []int{6}
+}
+
+func (m *RunRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RunRequest.Unmarshal(m, b)
+}
+func (m *RunRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RunRequest.Marshal(b, m, deterministic)
+}
+func (m *RunRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RunRequest.Merge(m, src)
+}
+func (m *RunRequest) XXX_Size() int {
+	return xxx_messageInfo_RunRequest.Size(m)
+}
+func (m *RunRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RunRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RunRequest proto.InternalMessageInfo
+
+func (m *RunRequest) GetBasedir() string {
+	if m != nil {
+		return m.Basedir
+	}
+	return ""
+}
+
+func (m *RunRequest) GetCommand() string {
+	if m != nil {
+		return m.Command
+	}
+	return ""
+}
+
+func (m *RunRequest) GetLimits() *Limits {
+	if m != nil {
+		return m.Limits
+	}
+	return nil
+}
+
+func (m *RunRequest) GetTestCase() *TestCase {
+	if m != nil {
+		return m.TestCase
+	}
+	return nil
+}
+
+func (m *RunRequest) GetCheck() *Check {
+	if m != nil {
+		return m.Check
+	}
+	return nil
+}
+
+func (m *RunRequest) GetLang() string {
+	if m != nil {
+		return m.Lang
+	}
+	return ""
+}
+
+func (m *RunRequest) GetArgs() []string {
+	if m != nil {
+		return m.Args
+	}
+	return nil
+}
+
+func (m *RunRequest) GetEnv() []string {
+	if m != nil {
+		return m.Env
+	}
+	return nil
+}
+
+type RunResponse struct {
+	Result *Result `protobuf:"bytes,1,opt,name=result,proto3" json:"result,omitempty"`
+	// at most stdout_limit and stderr_limit of the limits
+	Stdout               []byte   `protobuf:"bytes,2,opt,name=stdout,proto3" json:"stdout,omitempty"`
+	Stderr               []byte   `protobuf:"bytes,3,opt,name=stderr,proto3" json:"stderr,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RunResponse) Reset()         { *m = RunResponse{} }
+func (m *RunResponse) String() string { return proto.CompactTextString(m) }
+func (*RunResponse) ProtoMessage()    {}
+func (*RunResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6ba88695d5965b00, []int{7}
+}
+
+func (m *RunResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RunResponse.Unmarshal(m, b)
+}
+func (m *RunResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RunResponse.Marshal(b, m, deterministic)
+}
+func (m *RunResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RunResponse.Merge(m, src)
+}
+func (m *RunResponse) XXX_Size() int {
+	return xxx_messageInfo_RunResponse.Size(m)
+}
+func (m *RunResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_RunResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RunResponse proto.InternalMessageInfo
+
+func (m *RunResponse) GetResult() *Result {
+	if m != nil {
+		return m.Result
+	}
+	return nil
+}
+
+func (m *RunResponse) GetStdout() []byte {
+	if m != nil {
+		return m.Stdout
+	}
+	return nil
+}
+
+func (m *RunResponse) GetStderr() []byte {
+	if m != nil {
+		return m.Stderr
+	}
+	return nil
+}
+
+type CompileAndRunRequest struct {
+	// the language profile of compile runs the submission too
+	Compile              *CompileRequest `protobuf:"bytes,1,opt,name=compile,proto3" json:"compile,omitempty"`
+	Command              string          `protobuf:"bytes,2,opt,name=command,proto3" json:"command,omitempty"`
+	Limits               *Limits         `protobuf:"bytes,3,opt,name=limits,proto3" json:"limits,omitempty"`
+	TestCases            []*TestCase     `protobuf:"bytes,4,rep,name=test_cases,json=testCases,proto3" json:"test_cases,omitempty"`
+	Check                *Check          `protobuf:"bytes,5,opt,name=check,proto3" json:"check,omitempty"`
+	StopOnFailure        bool            `protobuf:"varint,6,opt,name=stop_on_failure,json=stopOnFailure,proto3" json:"stop_on_failure,omitempty"`
+	Args                 []string        `protobuf:"bytes,7,rep,name=args,proto3" json:"args,omitempty"`
+	Env                  []string        `protobuf:"bytes,8,rep,name=env,proto3" json:"env,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *CompileAndRunRequest) Reset()         { *m = CompileAndRunRequest{} }
+func (m *CompileAndRunRequest) String() string { return proto.CompactTextString(m) }
+func (*CompileAndRunRequest) ProtoMessage()    {}
+func (*CompileAndRunRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6ba88695d5965b00, []int{8}
+}
+
+func (m *CompileAndRunRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CompileAndRunRequest.Unmarshal(m, b)
+}
+func (m *CompileAndRunRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CompileAndRunRequest.Marshal(b, m, deterministic)
+}
+func (m *CompileAndRunRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CompileAndRunRequest.Merge(m, src)
+}
+func (m *CompileAndRunRequest) XXX_Size() int {
+	return xxx_messageInfo_CompileAndRunRequest.Size(m)
+}
+func (m *CompileAndRunRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CompileAndRunRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CompileAndRunRequest proto.InternalMessageInfo
+
+func (m *CompileAndRunRequest) GetCompile() *CompileRequest {
+	if m != nil {
+		return m.Compile
+	}
+	return nil
+}
+
+func (m *CompileAndRunRequest) GetCommand() string {
+	if m != nil {
+		return m.Command
+	}
+	return ""
+}
+
+func (m *CompileAndRunRequest) GetLimits() *Limits {
+	if m != nil {
+		return m.Limits
+	}
+	return nil
+}
+
+func (m *CompileAndRunRequest) GetTestCases() []*TestCase {
+	if m != nil {
+		return m.TestCases
+	}
+	return nil
+}
+
+func (m *CompileAndRunRequest) GetCheck() *Check {
+	if m != nil {
+		return m.Check
+	}
+	return nil
+}
+
+func (m *CompileAndRunRequest) GetStopOnFailure() bool {
+	if m != nil {
+		return m.StopOnFailure
+	}
+	return false
+}
+
+func (m *CompileAndRunRequest) GetArgs() []string {
+	if m != nil {
+		return m.Args
+	}
+	return nil
+}
+
+func (m *CompileAndRunRequest) GetEnv() []string {
+	if m != nil {
+		return m.Env
+	}
+	return nil
+}
+
+type Progress struct {
+	// Types that are valid to be assigned to Event:
+	//	*Progress_Compile
+	//	*Progress_Result
+	Event                isProgress_Event `protobuf_oneof:"event"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *Progress) Reset()         { *m = Progress{} }
+func (m *Progress) String() string { return proto.CompactTextString(m) }
+func (*Progress) ProtoMessage()    {}
+func (*Progress) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6ba88695d5965b00, []int{9}
+}
+
+func (m *Progress) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Progress.Unmarshal(m, b)
+}
+func (m *Progress) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Progress.Marshal(b, m, deterministic)
+}
+func (m *Progress) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Progress.Merge(m, src)
+}
+func (m *Progress) XXX_Size() int {
+	return xxx_messageInfo_Progress.Size(m)
+}
+func (m *Progress) XXX_DiscardUnknown() {
+	xxx_messageInfo_Progress.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Progress proto.InternalMessageInfo
+
+type isProgress_Event interface {
+	isProgress_Event()
+}
+
+type Progress_Compile struct {
+	Compile *CompileResponse `protobuf:"bytes,1,opt,name=compile,proto3,oneof"`
+}
+
+type Progress_Result struct {
+	Result *Result `protobuf:"bytes,2,opt,name=result,proto3,oneof"`
+}
+
+func (*Progress_Compile) isProgress_Event() {}
+
+func (*Progress_Result) isProgress_Event() {}
+
+func (m *Progress) GetEvent() isProgress_Event {
+	if m != nil {
+		return m.Event
+	}
+	return nil
+}
+
+func (m *Progress) GetCompile() *CompileResponse {
+	if x, ok := m.GetEvent().(*Progress_Compile); ok {
+		return x.Compile
+	}
+	return nil
+}
+
+func (m *Progress) GetResult() *Result {
+	if x, ok := m.GetEvent().(*Progress_Result); ok {
+		return x.Result
+	}
+	return nil
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*Progress) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*Progress_Compile)(nil),
+		(*Progress_Result)(nil),
+	}
+}
+
+type Difference struct {
+	Line                 int32    `protobuf:"varint,1,opt,name=line,proto3" json:"line,omitempty"`
+	Column               int32    `protobuf:"varint,2,opt,name=column,proto3" json:"column,omitempty"`
+	Expected             string   `protobuf:"bytes,3,opt,name=expected,proto3" json:"expected,omitempty"`
+	Actual               string   `protobuf:"bytes,4,opt,name=actual,proto3" json:"actual,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Difference) Reset()         { *m = Difference{} }
+func (m *Difference) String() string { return proto.CompactTextString(m) }
+func (*Difference) ProtoMessage()    {}
+func (*Difference) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6ba88695d5965b00, []int{10}
+}
+
+func (m *Difference) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Difference.Unmarshal(m, b)
+}
+func (m *Difference) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Difference.Marshal(b, m, deterministic)
+}
+func (m *Difference) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Difference.Merge(m, src)
+}
+func (m *Difference) XXX_Size() int {
+	return xxx_messageInfo_Difference.Size(m)
+}
+func (m *Difference) XXX_DiscardUnknown() {
+	xxx_messageInfo_Difference.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Difference proto.InternalMessageInfo
+
+func (m *Difference) GetLine() int32 {
+	if m != nil {
+		return m.Line
+	}
+	return 0
+}
+
+func (m *Difference) GetColumn() int32 {
+	if m != nil {
+		return m.Column
+	}
+	return 0
+}
+
+func (m *Difference) GetExpected() string {
+	if m != nil {
+		return m.Expected
+	}
+	return ""
+}
+
+func (m *Difference) GetActual() string {
+	if m != nil {
+		return m.Actual
+	}
+	return ""
+}
+
+// sandbox.Result, times in milliseconds and memory in KB
+type Result struct {
+	Case        string      `protobuf:"bytes,1,opt,name=case,proto3" json:"case,omitempty"`
+	Verdict     Verdict     `protobuf:"varint,2,opt,name=verdict,proto3,enum=judge.Verdict" json:"verdict,omitempty"`
+	ExitCode    int32       `protobuf:"varint,3,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
+	Signal      int32       `protobuf:"varint,4,opt,name=signal,proto3" json:"signal,omitempty"`
+	CpuTime     int64       `protobuf:"varint,5,opt,name=cpu_time,json=cpuTime,proto3" json:"cpu_time,omitempty"`
+	WallTime    int64       `protobuf:"varint,6,opt,name=wall_time,json=wallTime,proto3" json:"wall_time,omitempty"`
+	Memory      int64       `protobuf:"varint,7,opt,name=memory,proto3" json:"memory,omitempty"`
+	MemoryLimit int64       `protobuf:"varint,8,opt,name=memory_limit,json=memoryLimit,proto3" json:"memory_limit,omitempty"`
+	OomKilled   bool        `protobuf:"varint,9,opt,name=oom_killed,json=oomKilled,proto3" json:"oom_killed,omitempty"`
+	Error       string      `protobuf:"bytes,10,opt,name=error,proto3" json:"error,omitempty"`
+	Difference  *Difference `protobuf:"bytes,11,opt,name=difference,proto3" json:"difference,omitempty"`
+	Message     string      `protobuf:"bytes,12,opt,name=message,proto3" json:"message,omitempty"`
+	// who killed the command: timeout, cpu time, output limit, signal or oom, empty if it exited by itself
+	Killed               string   `protobuf:"bytes,13,opt,name=killed,proto3" json:"killed,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Result) Reset()         { *m = Result{} }
+func (m *Result) String() string { return proto.CompactTextString(m) }
+func (*Result) ProtoMessage()    {}
+func (*Result) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6ba88695d5965b00, []int{11}
+}
+
+func (m *Result) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Result.Unmarshal(m, b)
+}
+func (m *Result) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Result.Marshal(b, m, deterministic)
+}
+func (m *Result) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Result.Merge(m, src)
+}
+func (m *Result) XXX_Size() int {
+	return xxx_messageInfo_Result.Size(m)
+}
+func (m *Result) XXX_DiscardUnknown() {
+	xxx_messageInfo_Result.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Result proto.InternalMessageInfo
+
+func (m *Result) GetCase() string {
+	if m != nil {
+		return m.Case
+	}
+	return ""
+}
+
+func (m *Result) GetVerdict() Verdict {
+	if m != nil {
+		return m.Verdict
+	}
+	return Verdict_UNKNOWN
+}
+
+func (m *Result) GetExitCode() int32 {
+	if m != nil {
+		return m.ExitCode
+	}
+	return 0
+}
+
+func (m *Result) GetSignal() int32 {
+	if m != nil {
+		return m.Signal
+	}
+	return 0
+}
+
+func (m *Result) GetCpuTime() int64 {
+	if m != nil {
+		return m.CpuTime
+	}
+	return 0
+}
+
+func (m *Result) GetWallTime() int64 {
+	if m != nil {
+		return m.WallTime
+	}
+	return 0
+}
+
+func (m *Result) GetMemory() int64 {
+	if m != nil {
+		return m.Memory
+	}
+	return 0
+}
+
+func (m *Result) GetMemoryLimit() int64 {
+	if m != nil {
+		return m.MemoryLimit
+	}
+	return 0
+}
+
+func (m *Result) GetOomKilled() bool {
+	if m != nil {
+		return m.OomKilled
+	}
+	return false
+}
+
+func (m *Result) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *Result) GetDifference() *Difference {
+	if m != nil {
+		return m.Difference
+	}
+	return nil
+}
+
+func (m *Result) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func (m *Result) GetKilled() string {
+	if m != nil {
+		return m.Killed
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterEnum("judge.Verdict", Verdict_name, Verdict_value)
+	proto.RegisterType((*CompileRequest)(nil), "judge.CompileRequest")
+	proto.RegisterType((*CompileResponse)(nil), "judge.CompileResponse")
+	proto.RegisterType((*Diagnostic)(nil), "judge.Diagnostic")
+	proto.RegisterType((*Limits)(nil), "judge.Limits")
+	proto.RegisterType((*Check)(nil), "judge.Check")
+	proto.RegisterType((*TestCase)(nil), "judge.TestCase")
+	proto.RegisterType((*RunRequest)(nil), "judge.RunRequest")
+	proto.RegisterType((*RunResponse)(nil), "judge.RunResponse")
+	proto.RegisterType((*CompileAndRunRequest)(nil), "judge.CompileAndRunRequest")
+	proto.RegisterType((*Progress)(nil), "judge.Progress")
+	proto.RegisterType((*Difference)(nil), "judge.Difference")
+	proto.RegisterType((*Result)(nil), "judge.Result")
+}
+
+func init() {
+	proto.RegisterFile("judge/judge.proto", fileDescriptor_6ba88695d5965b00)
+}
+
+var fileDescriptor_6ba88695d5965b00 = []byte{
+	// 1332 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x56, 0xdb, 0x6e, 0xdb, 0x46,
+	0x13, 0x0e, 0x75, 0x22, 0x35, 0x94, 0x6d, 0x66, 0x91, 0x3f, 0x3f, 0xeb, 0x20, 0x80, 0x42, 0x20,
+	0xad, 0xd1, 0x06, 0x76, 0xab, 0xdc, 0xf4, 0xae, 0x48, 0x14, 0x05, 0x69, 0xa2, 0xc4, 0xee, 0x26,
+	0x6d, 0x80, 0xdc, 0x08, 0x14, 0xb9, 0x96, 0xb7, 0x22, 0xb9, 0xcc, 0x2e, 0xe9, 0x1c, 0x2e, 0xdb,
+	0x9b, 0xbe, 0x44, 0x5f, 0xa0, 0x2f, 0xd0, 0x87, 0xe8, 0xe3, 0xb4, 0x0f, 0x50, 0xcc, 0xee, 0x52,
+	0x07, 0xc3, 0x0d, 0x02, 0xb4, 0x37, 0xe2, 0x7c, 0x33, 0xa3, 0xe5, 0xee, 0x37, 0x33, 0xdf, 0x12,
+	0xae, 0xfe, 0x58, 0xa7, 0x0b, 0x76, 0xa4, 0x7f, 0x0f, 0x4b, 0x29, 0x2a, 0x41, 0xba, 0x1a, 0x44,
+	0xbf, 0xb6, 0x61, 0x77, 0x2c, 0xf2, 0x92, 0x67, 0x8c, 0xb2, 0xd7, 0x35, 0x53, 0x15, 0x09, 0xc1,
+	0x9d, 0xc7, 0x8a, 0xa5, 0x5c, 0x86, 0xad, 0xa1, 0x73, 0xd0, 0xa7, 0x0d, 0x24, 0xfb, 0xe0, 0x9d,
+	0xf2, 0x8c, 0x15, 0x71, 0xce, 0xc2, 0xb6, 0x0e, 0xad, 0x30, 0x09, 0xa0, 0xad, 0xaa, 0x34, 0xec,
+	0x68, 0x37, 0x9a, 0xb8, 0x4e, 0xc5, 0x73, 0x26, 0xea, 0x2a, 0xec, 0x0e, 0x9d, 0x83, 0x36, 0x6d,
+	0x20, 0x21, 0xd0, 0xc9, 0xe2, 0x62, 0x11, 0xf6, 0x74, 0xb2, 0xb6, 0xc9, 0x27, 0xe0, 0x15, 0x62,
+	0x96, 0xc4, 0xc9, 0x19, 0x0b, 0xdd, 0xa1, 0x73, 0xe0, 0x51, 0xb7, 0x10, 0x63, 0x84, 0xb8, 0x90,
+	0x12, 0xb5, 0x4c, 0x98, 0x0a, 0xbd, 0x61, 0x1b, 0x37, 0x64, 0x21, 0xb9, 0x05, 0x03, 0x5e, 0x24,
+	0x59, 0x9d, 0xb2, 0x59, 0xca, 0xa5, 0x0a, 0xfb, 0x3a, 0xec, 0x5b, 0xdf, 0x03, 0x2e, 0x95, 0x7e,
+	0x17, 0x2f, 0x96, 0x21, 0xe8, 0x90, 0xb6, 0xc9, 0x17, 0x70, 0xf5, 0x54, 0xc8, 0x39, 0x4f, 0x53,
+	0x56, 0xcc, 0xce, 0x58, 0x9c, 0x32, 0xa9, 0x42, 0x5f, 0x27, 0x04, 0xab, 0xc0, 0x23, 0xe3, 0x27,
+	0xd7, 0xa0, 0x7b, 0x9a, 0xc5, 0x0b, 0x15, 0x0e, 0x74, 0x82, 0x01, 0xe4, 0x3a, 0xf4, 0x72, 0x96,
+	0x0b, 0xf9, 0x2e, 0xdc, 0xd1, 0x67, 0xb3, 0x88, 0xdc, 0x80, 0x3e, 0x52, 0x32, 0x53, 0xfc, 0x3d,
+	0x0b, 0x77, 0x75, 0x48, 0x73, 0xf4, 0x9c, 0xbf, 0x67, 0xb8, 0x5d, 0x51, 0x57, 0x65, 0x5d, 0xcd,
+	0x32, 0x9e, 0xf3, 0x2a, 0xdc, 0xd3, 0x71, 0xdf, 0xf8, 0xa6, 0xe8, 0x7a, 0xdc, 0xf1, 0x9c, 0xa0,
+	0x45, 0xbd, 0xc4, 0x94, 0x44, 0x46, 0x7f, 0xb4, 0x60, 0x6f, 0x55, 0x1f, 0x55, 0x8a, 0x42, 0x31,
+	0xb2, 0x0b, 0x2d, 0xb1, 0x0c, 0x1d, 0x4d, 0x52, 0x4b, 0x2c, 0x71, 0x87, 0x4c, 0x4a, 0xd1, 0x94,
+	0xcb, 0x00, 0xdc, 0x09, 0x7b, 0xcb, 0xab, 0x59, 0x22, 0x52, 0x53, 0xad, 0x2e, 0xf5, 0xd0, 0x31,
+	0x16, 0x29, 0xdb, 0xac, 0x4d, 0xc7, 0x90, 0x6d, 0x21, 0xd6, 0x21, 0x29, 0xeb, 0x19, 0xc2, 0xa6,
+	0x6c, 0x49, 0x59, 0xbf, 0xe0, 0x39, 0xc3, 0x15, 0xdf, 0xc4, 0x59, 0x66, 0x62, 0x3d, 0x73, 0x36,
+	0x74, 0xe8, 0xe0, 0x9a, 0x10, 0x77, 0x8b, 0x90, 0xbb, 0xe0, 0xa7, 0x3c, 0x5e, 0x14, 0x42, 0x55,
+	0x3c, 0x31, 0x05, 0xf4, 0x47, 0x57, 0x0f, 0x4d, 0x2b, 0x3e, 0x58, 0x45, 0xe8, 0x66, 0x16, 0x2e,
+	0xa6, 0x3b, 0x21, 0x0d, 0xfb, 0x7a, 0x77, 0x16, 0x91, 0x03, 0x70, 0xcf, 0x99, 0x4c, 0x79, 0x52,
+	0x85, 0x30, 0x74, 0x0e, 0x76, 0x47, 0xbb, 0x76, 0xa1, 0x1f, 0x8c, 0x97, 0x36, 0x61, 0xe4, 0xc4,
+	0x70, 0xec, 0x1b, 0x4e, 0x34, 0x88, 0x7e, 0x72, 0x00, 0xd6, 0xef, 0xc4, 0xde, 0xc0, 0xda, 0x68,
+	0x2a, 0xfb, 0x54, 0xdb, 0xb6, 0x5f, 0x98, 0xe6, 0xb2, 0xab, 0xfb, 0x45, 0x9f, 0x2d, 0x11, 0x59,
+	0x9d, 0x17, 0x96, 0x47, 0x8b, 0x70, 0x1e, 0x14, 0x3b, 0x67, 0x92, 0x57, 0xef, 0x6c, 0xe3, 0xaf,
+	0x30, 0x32, 0x9c, 0x33, 0xa5, 0xe2, 0x85, 0xa1, 0xb1, 0x4f, 0x1b, 0x18, 0xfd, 0xd5, 0x82, 0x9e,
+	0x2e, 0xb6, 0xda, 0x2c, 0x83, 0xb3, 0x3d, 0x22, 0x6b, 0x3a, 0x5b, 0x5b, 0x74, 0x12, 0xe8, 0x24,
+	0x65, 0xad, 0xec, 0xf8, 0x69, 0xfb, 0x43, 0x25, 0x23, 0xd0, 0x29, 0x79, 0xaa, 0x6c, 0xb5, 0xb4,
+	0x8d, 0x65, 0xc4, 0xf4, 0xd7, 0xb5, 0xa8, 0x62, 0x5b, 0x2c, 0xfc, 0xff, 0x77, 0x88, 0xc9, 0x4d,
+	0x00, 0x0c, 0x96, 0x4c, 0x72, 0x91, 0x86, 0x9e, 0x8e, 0x62, 0xfa, 0x89, 0x76, 0xe0, 0x86, 0x97,
+	0x76, 0x5f, 0x7d, 0xf3, 0xa6, 0xe5, 0x7a, 0x63, 0xea, 0x4d, 0x5c, 0xea, 0xba, 0xb4, 0xa9, 0xb6,
+	0xb1, 0xdf, 0x55, 0x95, 0x8a, 0x55, 0xbf, 0xfb, 0xa6, 0xdf, 0x8d, 0x4f, 0x53, 0x60, 0x53, 0x98,
+	0x94, 0x36, 0x65, 0xb0, 0x4a, 0x61, 0x52, 0x9a, 0x94, 0xad, 0x91, 0xda, 0xb9, 0x30, 0x52, 0xa8,
+	0x0d, 0x2c, 0xc1, 0x71, 0xd1, 0xd3, 0xd6, 0xa7, 0x0d, 0x7c, 0xdc, 0xf1, 0x3a, 0x41, 0x97, 0x7a,
+	0xb5, 0x62, 0x12, 0x05, 0x2a, 0x7a, 0x0a, 0xdd, 0xf1, 0x19, 0x4b, 0x96, 0xb8, 0xd3, 0x1c, 0x67,
+	0xc2, 0x56, 0x1d, 0x6d, 0xf2, 0x7f, 0x70, 0xe3, 0xb9, 0x9a, 0xb1, 0x52, 0x69, 0xbe, 0x1d, 0xda,
+	0x8b, 0xe7, 0x6a, 0x52, 0x2a, 0x0c, 0x48, 0x96, 0xe9, 0x40, 0xdb, 0x04, 0x24, 0xcb, 0x26, 0xa5,
+	0x8a, 0x4e, 0xc0, 0x7b, 0xc1, 0x54, 0x35, 0x8e, 0x95, 0x66, 0x59, 0x6b, 0xa2, 0x5d, 0x11, 0x6d,
+	0x6c, 0x40, 0x5e, 0x94, 0x75, 0xa5, 0xd7, 0x1b, 0x50, 0x03, 0xb0, 0x63, 0xd8, 0xdb, 0x92, 0x25,
+	0x15, 0x4b, 0xf5, 0x7a, 0x03, 0xba, 0xc2, 0xd1, 0x9f, 0x0e, 0x00, 0xad, 0x8b, 0x4b, 0x64, 0xd8,
+	0xd9, 0x96, 0xe1, 0x10, 0xdc, 0x44, 0xe4, 0x79, 0x5c, 0xa4, 0x8d, 0x40, 0x5b, 0x48, 0x6e, 0x43,
+	0x4f, 0xd3, 0x68, 0x36, 0xeb, 0x8f, 0x76, 0xec, 0x78, 0x98, 0x76, 0xa3, 0x36, 0x48, 0xee, 0x40,
+	0xbf, 0x62, 0xaa, 0x9a, 0x25, 0xb1, 0x62, 0xba, 0x71, 0xfd, 0xd1, 0x9e, 0xcd, 0x6c, 0xce, 0x44,
+	0xbd, 0xaa, 0x39, 0x5d, 0x04, 0xdd, 0x04, 0x89, 0xd3, 0xbd, 0xe5, 0x8f, 0x06, 0x36, 0x53, 0x93,
+	0x49, 0x4d, 0xe8, 0x52, 0x45, 0x27, 0xd0, 0x89, 0xe5, 0x42, 0x85, 0xae, 0x51, 0x5e, 0xb4, 0xf1,
+	0x96, 0x60, 0xc5, 0xb9, 0x95, 0x71, 0x34, 0xa3, 0x14, 0x7c, 0x7d, 0x68, 0xab, 0x6d, 0xb7, 0xa1,
+	0x27, 0x99, 0xaa, 0x33, 0x33, 0x10, 0xeb, 0x13, 0x50, 0xed, 0xa4, 0x36, 0x88, 0xe3, 0x61, 0xba,
+	0xc8, 0xd2, 0x6b, 0x91, 0xf5, 0x33, 0x29, 0x2d, 0xbb, 0x16, 0x45, 0xbf, 0xb5, 0xe0, 0x9a, 0x95,
+	0xd1, 0x7b, 0x45, 0xba, 0xc1, 0xf2, 0x91, 0xe6, 0xb2, 0x6c, 0x54, 0xc0, 0x1f, 0xfd, 0xaf, 0x39,
+	0xde, 0xd6, 0xa5, 0x48, 0x9b, 0xac, 0x7f, 0x4f, 0xfe, 0x21, 0xc0, 0x8a, 0x7c, 0x15, 0x76, 0x86,
+	0xed, 0xcb, 0xd8, 0xef, 0x37, 0xec, 0xab, 0x8f, 0xa2, 0xff, 0x53, 0xd8, 0x53, 0x95, 0x28, 0x67,
+	0xa2, 0x98, 0x9d, 0xc6, 0x3c, 0xab, 0xa5, 0xd1, 0x67, 0x8f, 0xee, 0xa0, 0xfb, 0xb8, 0x78, 0x68,
+	0x9c, 0x1f, 0x59, 0x92, 0x12, 0xbc, 0x13, 0x29, 0x16, 0x92, 0x29, 0x45, 0x46, 0x17, 0xf9, 0xb9,
+	0x7e, 0x91, 0x1f, 0x53, 0xb8, 0x47, 0x57, 0xd6, 0x14, 0x7d, 0xb6, 0xaa, 0x61, 0xeb, 0x92, 0x1a,
+	0x3e, 0xba, 0xd2, 0x54, 0xf1, 0xbe, 0x0b, 0x5d, 0x76, 0xce, 0x8a, 0x2a, 0xca, 0x50, 0x96, 0x4f,
+	0x4f, 0x99, 0x64, 0x45, 0xb2, 0x96, 0x60, 0xe7, 0x52, 0x09, 0x6e, 0x5d, 0x94, 0xe0, 0xad, 0x81,
+	0xea, 0xaf, 0x07, 0x0a, 0xff, 0x13, 0x27, 0x55, 0x1d, 0x67, 0x56, 0x9c, 0x2d, 0x8a, 0x7e, 0x69,
+	0x43, 0xcf, 0xec, 0x45, 0xcb, 0x69, 0xac, 0xcc, 0xab, 0x50, 0x4e, 0xb1, 0xdf, 0x37, 0x2e, 0x99,
+	0xd6, 0x87, 0x2f, 0x99, 0x0f, 0x5e, 0xb1, 0xd8, 0x8a, 0x7c, 0x51, 0xd8, 0xb7, 0x77, 0xa9, 0x45,
+	0xff, 0xf9, 0x05, 0x7b, 0x0b, 0x06, 0xc6, 0xb2, 0x0a, 0x6a, 0x34, 0xdb, 0x37, 0x3e, 0xa3, 0xa0,
+	0x37, 0x01, 0x84, 0xc8, 0x67, 0x4b, 0x9e, 0x65, 0xab, 0x2b, 0xb5, 0x2f, 0x44, 0xfe, 0x44, 0x3b,
+	0xd6, 0xdf, 0x0f, 0xb0, 0xf9, 0xfd, 0xf0, 0x15, 0x40, 0xba, 0xaa, 0x89, 0x96, 0xee, 0xcd, 0x7b,
+	0xbb, 0x09, 0xd0, 0x8d, 0xa4, 0xcd, 0x3b, 0x6f, 0xb0, 0x75, 0xe7, 0xe1, 0xe6, 0xed, 0xdb, 0x77,
+	0x4c, 0x29, 0x0c, 0xfa, 0xfc, 0x67, 0x07, 0x5c, 0x4b, 0x2b, 0xf1, 0xc1, 0xfd, 0xfe, 0xd9, 0x93,
+	0x67, 0xc7, 0x2f, 0x9f, 0x05, 0x57, 0x48, 0x0f, 0x5a, 0xc7, 0x4f, 0x02, 0x87, 0xb8, 0xd0, 0x7e,
+	0x31, 0x9d, 0x04, 0x2d, 0x34, 0x9e, 0x4e, 0x27, 0x41, 0x1b, 0x23, 0x74, 0x12, 0x74, 0xd0, 0x71,
+	0x3c, 0x9d, 0x04, 0x5d, 0xed, 0x78, 0x18, 0xf4, 0xf0, 0xf9, 0x7c, 0x12, 0xb8, 0xf8, 0xbc, 0x37,
+	0x0e, 0x3c, 0x7c, 0xbe, 0xbc, 0x17, 0xf4, 0xf1, 0x79, 0x32, 0x09, 0x00, 0xff, 0xf0, 0xed, 0x74,
+	0x12, 0xf8, 0xe8, 0x18, 0x4f, 0x82, 0x01, 0x3a, 0xc6, 0xd3, 0x49, 0xb0, 0x33, 0xfa, 0xdd, 0x81,
+	0xc1, 0x63, 0x3c, 0xd8, 0x73, 0x26, 0xcf, 0x79, 0xc2, 0xc8, 0xd7, 0xe0, 0xda, 0xfe, 0x26, 0x97,
+	0xeb, 0xc1, 0xfe, 0x3f, 0x8c, 0x01, 0xb9, 0x03, 0x6d, 0x5a, 0x17, 0xa4, 0x21, 0x6a, 0xad, 0x34,
+	0xfb, 0x64, 0xd3, 0x65, 0xb3, 0xbf, 0x81, 0x9d, 0x2d, 0x55, 0x22, 0x37, 0xb6, 0x97, 0xdd, 0xd2,
+	0xaa, 0xfd, 0x46, 0x25, 0x9a, 0xe1, 0xfc, 0xd2, 0xb9, 0x1f, 0xbd, 0x1a, 0x2e, 0x78, 0x75, 0x56,
+	0xcf, 0x0f, 0x13, 0x91, 0x1f, 0xbd, 0xe2, 0x67, 0x6c, 0xca, 0xeb, 0x23, 0x15, 0x17, 0xe9, 0x5c,
+	0xbc, 0x35, 0xdf, 0xfb, 0xf3, 0x9e, 0xfe, 0xe0, 0xbf, 0xfb, 0xf7, 0x00, 0x97, 0xe1, 0xa5, 0xd1,
+	0x05, 0x0c, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// JudgeServiceClient is the client API for JudgeService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type JudgeServiceClient interface {
+	Compile(ctx context.Context, in *CompileRequest, opts ...grpc.CallOption) (*CompileResponse, error)
+	// runs the command against a single test case
+	Run(ctx context.Context, in *RunRequest, opts ...grpc.CallOption) (*RunResponse, error)
+	// compiles, then runs the command against every test case, streaming the compilation and then a result per test case
+	CompileAndRun(ctx context.Context, in *CompileAndRunRequest, opts ...grpc.CallOption) (JudgeService_CompileAndRunClient, error)
+}
+
+type judgeServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewJudgeServiceClient(cc grpc.ClientConnInterface) JudgeServiceClient {
+	return &judgeServiceClient{cc}
+}
+
+func (c *judgeServiceClient) Compile(ctx context.Context, in *CompileRequest, opts ...grpc.CallOption) (*CompileResponse, error) {
+	out := new(CompileResponse)
+	err := c.cc.Invoke(ctx, "/judge.JudgeService/Compile", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *judgeServiceClient) Run(ctx context.Context, in *RunRequest, opts ...grpc.CallOption) (*RunResponse, error) {
+	out := new(RunResponse)
+	err := c.cc.Invoke(ctx, "/judge.JudgeService/Run", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *judgeServiceClient) CompileAndRun(ctx context.Context, in *CompileAndRunRequest, opts ...grpc.CallOption) (JudgeService_CompileAndRunClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_JudgeService_serviceDesc.Streams[0], "/judge.JudgeService/CompileAndRun", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &judgeServiceCompileAndRunClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type JudgeService_CompileAndRunClient interface {
+	Recv() (*Progress, error)
+	grpc.ClientStream
+}
+
+type judgeServiceCompileAndRunClient struct {
+	grpc.ClientStream
+}
+
+func (x *judgeServiceCompileAndRunClient) Recv() (*Progress, error) {
+	m := new(Progress)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// JudgeServiceServer is the server API for JudgeService service.
+type JudgeServiceServer interface {
+	Compile(context.Context, *CompileRequest) (*CompileResponse, error)
+	// runs the command against a single test case
+	Run(context.Context, *RunRequest) (*RunResponse, error)
+	// compiles, then runs the command against every test case, streaming the compilation and then a result per test case
+	CompileAndRun(*CompileAndRunRequest, JudgeService_CompileAndRunServer) error
+}
+
+// UnimplementedJudgeServiceServer can be embedded to have forward compatible implementations.
+type UnimplementedJudgeServiceServer struct {
+}
+
+func (*UnimplementedJudgeServiceServer) Compile(ctx context.Context, req *CompileRequest) (*CompileResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Compile not implemented")
+}
+func (*UnimplementedJudgeServiceServer) Run(ctx context.Context, req *RunRequest) (*RunResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Run not implemented")
+}
+func (*UnimplementedJudgeServiceServer) CompileAndRun(req *CompileAndRunRequest, srv JudgeService_CompileAndRunServer) error {
+	return status.Errorf(codes.Unimplemented, "method CompileAndRun not implemented")
+}
+
+func RegisterJudgeServiceServer(s *grpc.Server, srv JudgeServiceServer) {
+	s.RegisterService(&_JudgeService_serviceDesc, srv)
+}
+
+func _JudgeService_Compile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JudgeServiceServer).Compile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/judge.JudgeService/Compile",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JudgeServiceServer).Compile(ctx, req.(*CompileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JudgeService_Run_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RunRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JudgeServiceServer).Run(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/judge.JudgeService/Run",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JudgeServiceServer).Run(ctx, req.(*RunRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JudgeService_CompileAndRun_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(CompileAndRunRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(JudgeServiceServer).CompileAndRun(m, &judgeServiceCompileAndRunServer{stream})
+}
+
+type JudgeService_CompileAndRunServer interface {
+	Send(*Progress) error
+	grpc.ServerStream
+}
+
+type judgeServiceCompileAndRunServer struct {
+	grpc.ServerStream
+}
+
+func (x *judgeServiceCompileAndRunServer) Send(m *Progress) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+var _JudgeService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "judge.JudgeService",
+	HandlerType: (*JudgeServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Compile",
+			Handler:    _JudgeService_Compile_Handler,
+		},
+		{
+			MethodName: "Run",
+			Handler:    _JudgeService_Run_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "CompileAndRun",
+			Handler:       _JudgeService_CompileAndRun_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "judge/judge.proto",
+}
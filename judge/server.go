@@ -0,0 +1,389 @@
+// +build linux
+// +build go1.12
+
+package judge
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/user"
+	"strconv"
+	"sync"
+
+	"github.com/ZiheLiu/sandbox/sandbox"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// KB, stdout and stderr of a run are buffered and sent back whole, both fit into the 4MB a gRPC client receives
+// by default
+const runOutputLimit = 1024
+
+// Server implements JudgeServiceServer on top of sandbox.Compile and sandbox.Run,
+// compiling or running at most as many submissions at a time as it has workers.
+type Server struct {
+	// holds an element per compilation or run in flight, shared with the other users of the sandboxes
+	workers chan struct{}
+	// nil if compilations are not cached
+	cache *sandbox.CompileCache
+	// the paths of the requests are below it
+	workRoot sandbox.HostDir
+	// the include dirs and files to link of compilations are below it, see sandbox.CompileConfig.ProblemDir
+	problemRoot sandbox.HostDir
+	// the seccomp profiles runs may choose
+	seccomp sandbox.SeccompPolicy
+	// the host user compilations and runs execute as, never root
+	username string
+	uid, gid int
+	// closed by Shutdown, which kills the sandboxes in flight
+	done     chan struct{}
+	once     sync.Once
+	inFlight sync.WaitGroup
+}
+
+// NewServer returns a Server compiling and running the submissions below workRoot with the files of the problems below
+// problemRoot as username, which must not be root. The runs may choose the seccomp profiles allowed by seccomp.
+// Its workers are the capacity of the channel, which e.g. the HTTP jobs of clike_daemon fill as well.
+//noinspection GoUnusedExportedFunction
+func NewServer(workers chan struct{}, cache *sandbox.CompileCache, workRoot, problemRoot sandbox.HostDir,
+	seccomp sandbox.SeccompPolicy, username string) (*Server, error) {
+	u, err := user.Lookup(username)
+	if err != nil {
+		return nil, err
+	}
+	uid, _ := strconv.Atoi(u.Uid)
+	gid, _ := strconv.Atoi(u.Gid)
+	if uid == 0 || gid == 0 {
+		return nil, fmt.Errorf("invalid user %s, must not be root", username)
+	}
+	return &Server{
+		workers:     workers,
+		cache:       cache,
+		workRoot:    workRoot,
+		problemRoot: problemRoot,
+		seccomp:     seccomp,
+		username:    username,
+		uid:         uid,
+		gid:         gid,
+		done:        make(chan struct{}),
+	}, nil
+}
+
+// Shutdown kills the sandboxes in flight and waits until every request returned.
+func (s *Server) Shutdown() {
+	s.once.Do(func() {
+		close(s.done)
+	})
+	s.inFlight.Wait()
+}
+
+// acquire blocks until a worker is free, release it when done.
+func (s *Server) acquire(ctx context.Context) error {
+	select {
+	case s.workers <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	case <-s.done:
+		return status.Error(codes.Unavailable, "server is shutting down")
+	}
+}
+
+func (s *Server) release() {
+	<-s.workers
+}
+
+func (s *Server) Compile(ctx context.Context, req *CompileRequest) (*CompileResponse, error) {
+	s.inFlight.Add(1)
+	defer s.inFlight.Done()
+
+	cfg, err := s.compileConfig(req)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := s.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer s.release()
+	return s.compile(ctx, cfg), nil
+}
+
+func (s *Server) Run(ctx context.Context, req *RunRequest) (*RunResponse, error) {
+	s.inFlight.Add(1)
+	defer s.inFlight.Done()
+
+	cfg, judge, err := s.runConfig(req.Basedir, req.Command, req.Lang, req.Args, req.Env, req.Limits, req.Check)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	testCase := req.TestCase
+	if testCase == nil {
+		testCase = &TestCase{}
+	}
+
+	if err := s.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer s.release()
+	result, stdout, stderr := s.run(ctx, cfg, judge, testCase)
+	return &RunResponse{Result: result, Stdout: stdout, Stderr: stderr}, nil
+}
+
+func (s *Server) CompileAndRun(req *CompileAndRunRequest, stream JudgeService_CompileAndRunServer) error {
+	s.inFlight.Add(1)
+	defer s.inFlight.Done()
+
+	ctx := stream.Context()
+	compile := req.Compile
+	if compile == nil {
+		compile = &CompileRequest{}
+	}
+	compileCfg, err := s.compileConfig(compile)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	cfg, judge, err := s.runConfig(compileCfg.BaseDir, req.Command, compile.Lang, req.Args, req.Env, req.Limits, req.Check)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// the worker is released between the steps, so that long batches do not starve short submissions
+	if err := s.acquire(ctx); err != nil {
+		return err
+	}
+	compiled := s.compile(ctx, compileCfg)
+	s.release()
+	if err := stream.Send(&Progress{Event: &Progress_Compile{Compile: compiled}}); err != nil {
+		return err
+	}
+	if !compiled.Ok {
+		return nil
+	}
+
+	for _, testCase := range req.TestCases {
+		if err := s.acquire(ctx); err != nil {
+			return err
+		}
+		result, _, _ := s.run(ctx, cfg, judge, testCase)
+		s.release()
+		if err := stream.Send(&Progress{Event: &Progress_Result{Result: result}}); err != nil {
+			return err
+		}
+		if req.StopOnFailure && result.Verdict != Verdict_OK && result.Verdict != Verdict_AC {
+			break
+		}
+	}
+	return nil
+}
+
+// withShutdown returns a context done with ctx or on Shutdown.
+func (s *Server) withShutdown(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-s.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+func (s *Server) compile(ctx context.Context, cfg *sandbox.CompileConfig) *CompileResponse {
+	ctx, cancel := s.withShutdown(ctx)
+	defer cancel()
+
+	cfg.Cache = s.cache
+	r := sandbox.CompileWithResult(ctx, cfg)
+	response := &CompileResponse{
+		Ok:       r.OK,
+		Verdict:  Verdict(Verdict_value[string(r.Verdict)]),
+		Limit:    r.Limit,
+		Error:    r.Error,
+		ExitCode: int32(r.ExitCode),
+		Timeout:  r.Timeout,
+		CpuTime:  r.CPUTime,
+		WallTime: r.WallTime,
+		Memory:   r.Memory,
+		Cached:   r.Cached,
+	}
+	for _, d := range r.Diagnostics {
+		response.Diagnostics = append(response.Diagnostics, &Diagnostic{
+			File: d.File, Line: int32(d.Line), Column: int32(d.Column), Severity: d.Severity, Message: d.Message,
+		})
+	}
+	return response
+}
+
+// run runs cfg against testCase, the cgroup is killed once ctx is done.
+func (s *Server) run(ctx context.Context, cfg *sandbox.Config, judge sandbox.Judge, testCase *TestCase) (*Result, []byte, []byte) {
+	ctx, cancel := s.withShutdown(ctx)
+	defer cancel()
+
+	// the cgroup is destroyed once Run returns
+	finished := make(chan struct{})
+	runCfg := *cfg
+	runCfg.Started = func(cg *sandbox.CGroup) {
+		go func() {
+			select {
+			case <-ctx.Done():
+				_ = cg.Kill()
+			case <-finished:
+			}
+		}()
+	}
+
+	// bounded by the output limits, see runConfig
+	var stdout, stderr bytes.Buffer
+	result := sandbox.Run(&runCfg, bytes.NewReader(testCase.Input), &stdout, &stderr)
+	close(finished)
+	if judge != nil {
+		judge.Judge(result, bytes.NewReader(testCase.Input), bytes.NewReader(stdout.Bytes()), bytes.NewReader(testCase.Expected))
+	}
+	result.Case = testCase.Name
+	return toResult(result), stdout.Bytes(), stderr.Bytes()
+}
+
+// compileConfig fills the zero fields of req with the defaults of clike_compiler, the compiler is the one of the
+// language profile and basedir is resolved below the work root.
+func (s *Server) compileConfig(req *CompileRequest) (*sandbox.CompileConfig, error) {
+	cfg := sandbox.DefaultCompileConfig()
+	cfg.Username = s.username
+	if req.Lang != "" {
+		cfg.Lang = req.Lang
+	}
+	var err error
+	if cfg.BaseDir, err = s.workRoot.Resolve(req.Basedir); err != nil {
+		return nil, err
+	}
+	if req.Filename != "" {
+		cfg.Filename = req.Filename
+	}
+	if req.Std != "" {
+		cfg.Std = req.Std
+	}
+	if req.Timeout != 0 {
+		cfg.Timeout = req.Timeout
+	}
+	if req.Memory != 0 {
+		cfg.Memory = req.Memory
+	}
+	if req.FileSize != 0 {
+		cfg.FileSize = req.FileSize
+	}
+	if req.OutputLimit != 0 {
+		cfg.OutputLimit = req.OutputLimit
+	}
+	cfg.NoCache = req.NoCache
+	cfg.Sources, cfg.ForbiddenHeaders, cfg.Flags = req.Sources, req.ForbiddenHeaders, req.Flags
+	cfg.IncludeDirs, cfg.Link, cfg.ProblemDir = req.IncludeDirs, req.Link, s.problemRoot
+	return cfg, nil
+}
+
+// runConfig fills the zero fields of l with the defaults of clike_container and validates them,
+// the submission below the work root is run by the language profile lang unless it is empty.
+func (s *Server) runConfig(basedir, command, lang string, args, env []string, l *Limits, check *Check) (*sandbox.Config, sandbox.Judge, error) {
+	if l == nil {
+		l = &Limits{}
+	}
+	or := func(v, defaultValue int64) int64 {
+		if v == 0 {
+			return defaultValue
+		}
+		return v
+	}
+	orString := func(v, defaultValue string) string {
+		if v == "" {
+			return defaultValue
+		}
+		return v
+	}
+
+	basedir, err := s.workRoot.Resolve(basedir)
+	if err != nil {
+		return nil, nil, err
+	}
+	seccomp, err := s.seccomp.Resolve(l.Seccomp)
+	if err != nil {
+		return nil, nil, err
+	}
+	if seccomp == "" {
+		if seccomp, err = sandbox.DefaultSeccompProfile(lang); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	defaults := sandbox.DefaultLimits()
+	cfg := &sandbox.Config{
+		BaseDir: basedir,
+		Command: orString(command, "./Main"),
+		Args:    args,
+		Env:     env,
+		Timeout: or(l.Timeout, 2000),
+		CPUTime: l.CpuTime,
+		Limits: &sandbox.Limits{
+			Memory:    or(l.Memory, defaults.Memory),
+			CPUs:      orString(l.Cpus, defaults.CPUs),
+			Pids:      or(l.Pids, defaults.Pids),
+			CPUQuota:  or(l.CpuQuota, defaults.CPUQuota),
+			CPUPeriod: or(l.CpuPeriod, defaults.CPUPeriod),
+			KMemory:   or(l.Kmemory, defaults.KMemory),
+			Swap:      or(l.Swap, defaults.Swap),
+		},
+		Seccomp:     seccomp,
+		StdoutLimit: or(l.StdoutLimit, runOutputLimit),
+		StderrLimit: or(l.StderrLimit, runOutputLimit),
+		FileSize:    l.FileSize,
+		UID:         s.uid,
+		GID:         s.gid,
+		Quiet:       true,
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+	if lang != "" {
+		profile, err := sandbox.LookupLanguageProfile(lang)
+		if err != nil {
+			return nil, nil, err
+		}
+		cfg = profile.RunConfig(cfg)
+	}
+
+	if check == nil || check.Mode == "" {
+		return cfg, nil, nil
+	}
+	absEpsilon, relEpsilon := check.AbsEps, check.RelEps
+	if absEpsilon == 0 {
+		absEpsilon = 1e-6
+	}
+	if relEpsilon == 0 {
+		relEpsilon = 1e-6
+	}
+	checker, err := sandbox.NewChecker(sandbox.CheckMode(check.Mode), absEpsilon, relEpsilon)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg, checker, nil
+}
+
+func toResult(r *sandbox.Result) *Result {
+	result := &Result{
+		Case:        r.Case,
+		Verdict:     Verdict(Verdict_value[string(r.Verdict)]),
+		ExitCode:    int32(r.ExitCode),
+		Signal:      int32(r.Signal),
+		CpuTime:     r.CPUTime,
+		WallTime:    r.WallTime,
+		Memory:      r.Memory,
+		MemoryLimit: r.MemoryLimit,
+		OomKilled:   r.OOMKilled,
+		Killed:      string(r.Killed),
+		Error:       r.Error,
+		Message:     r.Message,
+	}
+	if d := r.Difference; d != nil {
+		result.Difference = &Difference{Line: int32(d.Line), Column: int32(d.Column), Expected: d.Expected, Actual: d.Actual}
+	}
+	return result
+}
@@ -8,135 +8,457 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 )
 
 const (
-	cgCPUSetPathPrefix = "/sys/fs/cgroup/cpuset/"
-	cgCPUPathPrefix    = "/sys/fs/cgroup/cpu/"
-	cgPidPathPrefix    = "/sys/fs/cgroup/pids/"
-	cgMemoryPathPrefix = "/sys/fs/cgroup/memory/"
+	cgRootPath = "/sys/fs/cgroup/"
+
+	cgCPUSetPathPrefix  = "/sys/fs/cgroup/cpuset/"
+	cgCPUPathPrefix     = "/sys/fs/cgroup/cpu/"
+	cgCPUAcctPathPrefix = "/sys/fs/cgroup/cpuacct/"
+	cgPidPathPrefix     = "/sys/fs/cgroup/pids/"
+	cgMemoryPathPrefix  = "/sys/fs/cgroup/memory/"
+	cgFreezerPathPrefix = "/sys/fs/cgroup/freezer/"
+
+	// statfs(2) f_type of a cgroup v2 mount, linux/magic.h
+	cgroup2SuperMagic = 0x63677270
+
+	// how long Destroy() waits for the killed tasks to leave the cgroup
+	cgDestroyTimeout = time.Second
 )
 
+// CGroup is the cgroup of one container: one directory per controller on v1, a single directory on v2.
+// It is created by InitCGroup, tasks join it by AddProcess and it is removed by Destroy.
+type CGroup struct {
+	ID string
+	v2 bool
+
+	// the accounting before the last Reset, which Stats leaves out
+	base CGroupStats
+	// memory.peak of v2 reset by the last Reset, it is reset for this open file only
+	peak *os.File
+	// the memory peak could not be reset, so Stats reports none
+	peakStale bool
+}
+
+// CGroupStats is what the kernel accounted for all tasks of a CGroup.
+type CGroupStats struct {
+	// number of tasks killed by the OOM killer
+	OOMKills int64
+	// highest memory usage in bytes, page cache and kernel memory included, 0 if the kernel does not record it
+	MemoryPeak int64
+	// number of tasks currently in the cgroup
+	Pids int64
+	// cpu time consumed by all tasks, dead ones included
+	CPUUsage  time.Duration
+	CPUUser   time.Duration
+	CPUSystem time.Duration
+}
+
+// cgroupFile is a control file and the value written to it.
+// Files are written in order: limits first, so no task ever runs unconstrained.
+type cgroupFile struct {
+	name  string
+	value string
+}
+
+// isCGroupV2 reports whether /sys/fs/cgroup is the unified hierarchy.
+// The hybrid layout, i.e. v1 controllers plus /sys/fs/cgroup/unified, counts as v1.
+func isCGroupV2() bool {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(cgRootPath, &st); err != nil {
+		return false
+	}
+	return st.Type == cgroup2SuperMagic
+}
+
+// InitCGroup creates the cgroup of the container and sets its limits.
+// No task is added, see CGroup.AddProcess.
 //noinspection GoUnusedExportedFunction
-func InitCGroup(pid, containerID, memory string, cpus string) error {
-	_, _ = os.Stderr.WriteString(fmt.Sprintf("DEBUG: InitCGroup(%s, %s, %s) starting...\n", pid, containerID, memory))
+func InitCGroup(containerID string, limits *Limits) (*CGroup, error) {
+	_, _ = logger.WriteString(fmt.Sprintf("DEBUG: InitCGroup(%s, %+v) starting...\n", containerID, *limits))
+
+	if err := limits.Validate(); err != nil {
+		_, _ = logger.WriteString(fmt.Sprintf("DEBUG: limits.Validate() failed, err: %s\n", err.Error()))
+		return nil, err
+	}
 
-	dirs := []string{
-		filepath.Join(cgCPUSetPathPrefix, containerID),
-		filepath.Join(cgCPUPathPrefix, containerID),
-		filepath.Join(cgPidPathPrefix, containerID),
-		filepath.Join(cgMemoryPathPrefix, containerID),
+	cg := &CGroup{ID: containerID, v2: isCGroupV2()}
+	if cg.v2 {
+		if err := initCGroupV2(containerID, limits); err != nil {
+			_, _ = logger.WriteString(fmt.Sprintf("DEBUG: initCGroupV2(%s) failed, err: %s\n", containerID, err.Error()))
+			_ = cg.Destroy()
+			return nil, err
+		}
+		_, _ = logger.WriteString(fmt.Sprintf("DEBUG: InitCGroup(%s) done\n", containerID))
+		return cg, nil
 	}
 
-	for _, dir := range dirs {
+	for _, dir := range cg.dirs() {
 		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
-			_, _ = os.Stderr.WriteString(fmt.Sprintf("DEBUG: os.MkdirAll(%s, os.ModePerm) failed, err: %s\n", dir, err.Error()))
-			return err
+			_, _ = logger.WriteString(fmt.Sprintf("DEBUG: os.MkdirAll(%s, os.ModePerm) failed, err: %s\n", dir, err.Error()))
+			_ = cg.Destroy()
+			return nil, err
 		}
 	}
 
-	if err := cpusetCGroup(pid, containerID, cpus); err != nil {
-		_, _ = os.Stderr.WriteString(fmt.Sprintf("DEBUG: cpusetCGroup(%s, %s, %s) failed, err: %s\n", pid, containerID, cpus, err.Error()))
-		return err
+	if err := cpusetCGroup(containerID, limits); err != nil {
+		_, _ = logger.WriteString(fmt.Sprintf("DEBUG: cpusetCGroup(%s) failed, err: %s\n", containerID, err.Error()))
+		_ = cg.Destroy()
+		return nil, err
 	}
 
-	if err := cpuCGroup(pid, containerID); err != nil {
-		_, _ = os.Stderr.WriteString(fmt.Sprintf("DEBUG: cpuCGroup(%s, %s) failed, err: %s\n", pid, containerID, err.Error()))
-		return err
+	if err := cpuCGroup(containerID, limits); err != nil {
+		_, _ = logger.WriteString(fmt.Sprintf("DEBUG: cpuCGroup(%s) failed, err: %s\n", containerID, err.Error()))
+		_ = cg.Destroy()
+		return nil, err
 	}
 
-	if err := pidCGroup(pid, containerID); err != nil {
-		_, _ = os.Stderr.WriteString(fmt.Sprintf("DEBUG: pidCGroup(%s, %s) failed, err: %s\n", pid, containerID, err.Error()))
-		return err
+	if err := pidCGroup(containerID, limits); err != nil {
+		_, _ = logger.WriteString(fmt.Sprintf("DEBUG: pidCGroup(%s) failed, err: %s\n", containerID, err.Error()))
+		_ = cg.Destroy()
+		return nil, err
+	}
+
+	if err := memoryCGroup(containerID, limits); err != nil {
+		_, _ = logger.WriteString(fmt.Sprintf("DEBUG: memoryCGroup(%s) failed, err: %s\n", containerID, err.Error()))
+		_ = cg.Destroy()
+		return nil, err
 	}
 
-	if err := memoryCGroup(pid, containerID, memory); err != nil {
-		_, _ = os.Stderr.WriteString(fmt.Sprintf("DEBUG: memoryCGroup(%s, %s) failed, err: %s\n", pid, containerID, err.Error()))
+	_, _ = logger.WriteString(fmt.Sprintf("DEBUG: InitCGroup(%s) done\n", containerID))
+	return cg, nil
+}
+
+// SetLimits changes the limits of the cgroup, e.g. of a pooled Sandbox between runs.
+func (cg *CGroup) SetLimits(limits *Limits) error {
+	if err := limits.Validate(); err != nil {
 		return err
 	}
+	if cg.v2 {
+		return limitCGroupV2(filepath.Join(cgV2PathPrefix, cg.ID), limits)
+	}
+	for _, set := range []func(string, *Limits) error{cpusetCGroup, cpuCGroup, pidCGroup, memoryCGroup} {
+		if err := set(cg.ID, limits); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	_, _ = os.Stderr.WriteString(fmt.Sprintf("DEBUG: InitCGroup(%s, %s, %s) done\n", pid, containerID, memory))
+// Reset starts the accounting of the cgroup over, e.g. of a pooled Sandbox between runs. The memory peak is reset
+// to the current usage, the cumulative counters are left out of Stats from now on.
+func (cg *CGroup) Reset() error {
+	cg.base, cg.peakStale = CGroupStats{}, false
+	if cg.v2 {
+		// a write to memory.peak of linux 6.12+ only resets it for the writer, there is no global reset, so Stats
+		// reads the peak through the file written here
+		if err := cg.resetPeak(); err != nil {
+			_, _ = logger.WriteString(fmt.Sprintf("DEBUG: resetting the memory peak of %s failed, err: %s\n", cg.ID, err.Error()))
+			cg.peakStale = true
+		}
+	} else if err := ioutil.WriteFile(cg.path("memory", "memory.max_usage_in_bytes"), []byte("0"), 0644); err != nil {
+		return err
+	}
+	stats, err := cg.Stats()
+	if err != nil {
+		return err
+	}
+	cg.base = *stats
 	return nil
 }
 
-// https://www.kernel.org/doc/Documentation/cgroup-v1/cpusets.txt
-func cpusetCGroup(pid, containerID string, cpus string) error {
-	cgCPUsetPath := filepath.Join(cgCPUSetPathPrefix, containerID)
-	mapping := map[string]string{
-		"cpuset.mems": "0",
-		"cpuset.cpus": cpus,
-		"tasks":       pid,
-	}
-
-	for key, value := range mapping {
-		path := filepath.Join(cgCPUsetPath, key)
-		if err := ioutil.WriteFile(path, []byte(value), 0644); err != nil {
-			_, _ = os.Stderr.WriteString(fmt.Sprintf("Writing [%s] to file: %s failed\n", value, path))
+// resetPeak resets memory.peak of v2 to the current usage for reads through cg.peak.
+func (cg *CGroup) resetPeak() error {
+	if cg.peak == nil {
+		f, err := os.OpenFile(cg.path("memory", "memory.peak"), os.O_RDWR, 0)
+		if err != nil {
 			return err
 		}
-		c, _ := ioutil.ReadFile(path)
-		_, _ = os.Stderr.WriteString(fmt.Sprintf("DEBUG: Content of %s is: %s", path, c))
+		cg.peak = f
+	}
+	if _, err := cg.peak.WriteAt([]byte("reset"), 0); err != nil {
+		// the kernel is older than linux 6.12
+		_ = cg.peak.Close()
+		cg.peak = nil
+		return err
 	}
 	return nil
 }
 
-// https://www.kernel.org/doc/Documentation/scheduler/sched-bwc.txt
-func cpuCGroup(pid, containerID string) error {
-	cgCPUPath := filepath.Join(cgCPUPathPrefix, containerID)
-	mapping := map[string]string{
-		"tasks":            pid,
-		"cpu.cfs_quota_us": "10000",
+// dirs returns the directories of the cgroup, the freezer of v1 last.
+func (cg *CGroup) dirs() []string {
+	if cg.v2 {
+		return []string{filepath.Join(cgV2PathPrefix, cg.ID)}
+	}
+	return []string{
+		filepath.Join(cgCPUSetPathPrefix, cg.ID),
+		filepath.Join(cgCPUPathPrefix, cg.ID),
+		// the same directory as cpu if both are mounted together as cpu,cpuacct
+		filepath.Join(cgCPUAcctPathPrefix, cg.ID),
+		filepath.Join(cgPidPathPrefix, cg.ID),
+		filepath.Join(cgMemoryPathPrefix, cg.ID),
+		filepath.Join(cgFreezerPathPrefix, cg.ID),
+	}
+}
+
+// path returns the path of the control file name, which belongs to controller on v1, e.g. "memory".
+func (cg *CGroup) path(controller, name string) string {
+	if cg.v2 {
+		return filepath.Join(cgV2PathPrefix, cg.ID, name)
 	}
+	return filepath.Join(cgRootPath, controller, cg.ID, name)
+}
 
-	for key, value := range mapping {
-		path := filepath.Join(cgCPUPath, key)
-		if err := ioutil.WriteFile(path, []byte(value), 0644); err != nil {
-			_, _ = os.Stderr.WriteString(fmt.Sprintf("Writing [%s] to file: %s failed\n", value, path))
+// AddProcess moves the process pid with all its threads into the cgroup.
+// Children forked afterwards are in the cgroup as well.
+func (cg *CGroup) AddProcess(pid int) error {
+	for _, dir := range cg.dirs() {
+		if err := writeCGroupFiles(dir, []cgroupFile{{"cgroup.procs", strconv.Itoa(pid)}}); err != nil {
 			return err
 		}
-		c, _ := ioutil.ReadFile(path)
-		_, _ = os.Stderr.WriteString(fmt.Sprintf("DEBUG: Content of %s is: %s", path, c))
 	}
 	return nil
 }
 
-// https://www.kernel.org/doc/Documentation/cgroup-v1/pids.txt
-func pidCGroup(pid, containerID string) error {
-	cgPidPath := filepath.Join(cgPidPathPrefix, containerID)
-	mapping := map[string]string{
-		"cgroup.procs": pid,
-		"pids.max":     "64",
+// Stats reads the accounting of the cgroup.
+func (cg *CGroup) Stats() (*CGroupStats, error) {
+	stats := &CGroupStats{}
+
+	var oom map[string]string
+	if cg.v2 {
+		oom = readCGroupStats(cg.path("memory", "memory.events"))
+	} else {
+		oom = readCGroupStats(cg.path("memory", "memory.oom_control"))
 	}
+	stats.OOMKills, _ = strconv.ParseInt(oom["oom_kill"], 10, 64)
 
-	for key, value := range mapping {
-		path := filepath.Join(cgPidPath, key)
-		if err := ioutil.WriteFile(path, []byte(value), 0644); err != nil {
-			_, _ = os.Stderr.WriteString(fmt.Sprintf("Writing [%s] to file: %s failed\n", value, path))
-			return err
+	// memory.peak is linux 5.19+
+	if cg.peak != nil {
+		c := make([]byte, 32)
+		n, _ := cg.peak.ReadAt(c, 0)
+		stats.MemoryPeak, _ = strconv.ParseInt(strings.TrimSpace(string(c[:n])), 10, 64)
+	} else if cg.v2 {
+		stats.MemoryPeak, _ = readCGroupInt(cg.path("memory", "memory.peak"))
+	} else {
+		stats.MemoryPeak, _ = readCGroupInt(cg.path("memory", "memory.max_usage_in_bytes"))
+	}
+
+	pids, err := readCGroupInt(cg.path("pids", "pids.current"))
+	if err != nil {
+		return nil, err
+	}
+	stats.Pids = pids
+
+	if cg.v2 {
+		cpu := readCGroupStats(cg.path("cpu", "cpu.stat"))
+		usage, _ := strconv.ParseInt(cpu["usage_usec"], 10, 64)
+		user, _ := strconv.ParseInt(cpu["user_usec"], 10, 64)
+		system, _ := strconv.ParseInt(cpu["system_usec"], 10, 64)
+		stats.CPUUsage = time.Duration(usage) * time.Microsecond
+		stats.CPUUser = time.Duration(user) * time.Microsecond
+		stats.CPUSystem = time.Duration(system) * time.Microsecond
+		return cg.sinceReset(stats), nil
+	}
+
+	usage, err := readCGroupInt(cg.path("cpuacct", "cpuacct.usage"))
+	if err != nil {
+		return nil, err
+	}
+	stats.CPUUsage = time.Duration(usage)
+	// in USER_HZ, which is 100 on every architecture that matters
+	cpu := readCGroupStats(cg.path("cpuacct", "cpuacct.stat"))
+	user, _ := strconv.ParseInt(cpu["user"], 10, 64)
+	system, _ := strconv.ParseInt(cpu["system"], 10, 64)
+	stats.CPUUser = time.Duration(user) * 10 * time.Millisecond
+	stats.CPUSystem = time.Duration(system) * 10 * time.Millisecond
+
+	return cg.sinceReset(stats), nil
+}
+
+// sinceReset leaves out of stats what was accounted before the last Reset.
+func (cg *CGroup) sinceReset(stats *CGroupStats) *CGroupStats {
+	stats.OOMKills -= cg.base.OOMKills
+	stats.CPUUsage -= cg.base.CPUUsage
+	stats.CPUUser -= cg.base.CPUUser
+	stats.CPUSystem -= cg.base.CPUSystem
+	if cg.peakStale {
+		stats.MemoryPeak = 0
+	}
+	return stats
+}
+
+// Procs lists the pids of the processes in the cgroup.
+func (cg *CGroup) Procs() ([]int, error) {
+	c, err := ioutil.ReadFile(cg.path("pids", "cgroup.procs"))
+	if err != nil {
+		return nil, err
+	}
+	var pids []int
+	for _, pid := range strings.Fields(string(c)) {
+		if p, err := strconv.Atoi(pid); err == nil {
+			pids = append(pids, p)
+		}
+	}
+	return pids, nil
+}
+
+// Kill sends SIGKILL to every task of the cgroup, including those forked while killing.
+func (cg *CGroup) Kill() error {
+	return cg.KillExcept(0)
+}
+
+// KillExcept sends SIGKILL to every task of the cgroup but the process spare, 0 for none, e.g. the init of a
+// pooled Sandbox.
+func (cg *CGroup) KillExcept(spare int) error {
+	// cgroup.kill of linux 5.14+ does it atomically
+	if cg.v2 && spare == 0 {
+		if err := ioutil.WriteFile(cg.path("", "cgroup.kill"), []byte("1"), 0644); err == nil {
+			return nil
+		}
+	}
+
+	// freeze the tasks, so no one forks in between, kill them all, then thaw them to let them die
+	freezer, frozen, thawed := cg.path("freezer", "freezer.state"), "FROZEN", "THAWED"
+	if cg.v2 {
+		freezer, frozen, thawed = cg.path("", "cgroup.freeze"), "1", "0"
+	}
+	if err := ioutil.WriteFile(freezer, []byte(frozen), 0644); err != nil {
+		_, _ = logger.WriteString(fmt.Sprintf("Writing [%s] to file: %s failed\n", frozen, freezer))
+		return err
+	}
+	defer func() {
+		_ = ioutil.WriteFile(freezer, []byte(thawed), 0644)
+	}()
+
+	pids, err := cg.Procs()
+	if err != nil {
+		return err
+	}
+	for _, pid := range pids {
+		if pid != spare {
+			_ = syscall.Kill(pid, syscall.SIGKILL)
 		}
-		c, _ := ioutil.ReadFile(path)
-		_, _ = os.Stderr.WriteString(fmt.Sprintf("DEBUG: Content of %s is: %s", path, c))
 	}
 	return nil
 }
 
-// https://www.kernel.org/doc/Documentation/cgroup-v1/memory.txt
-func memoryCGroup(pid, containerID, memory string) error {
-	cgMemoryPath := filepath.Join(cgMemoryPathPrefix, containerID)
-	mapping := map[string]string{
-		"memory.kmem.limit_in_bytes": "64m",
-		"tasks":                      pid,
-		"memory.limit_in_bytes":      fmt.Sprintf("%sK", memory),
-	}
-
-	for key, value := range mapping {
-		path := filepath.Join(cgMemoryPath, key)
-		if err := ioutil.WriteFile(path, []byte(value), 0644); err != nil {
-			_, _ = os.Stderr.WriteString(fmt.Sprintf("Writing [%s] to file: %s failed\n", value, path))
+// Destroy kills the remaining tasks and removes the cgroup, no release_agent is needed.
+func (cg *CGroup) Destroy() error {
+	_ = cg.Kill()
+	if cg.peak != nil {
+		_ = cg.peak.Close()
+		cg.peak = nil
+	}
+
+	var lastErr error
+	deadline := time.Now().Add(cgDestroyTimeout)
+	for _, dir := range cg.dirs() {
+		for {
+			// killed tasks keep the cgroup busy until they are reaped
+			err := syscall.Rmdir(dir)
+			if err == nil || err == syscall.ENOENT {
+				break
+			}
+			if err != syscall.EBUSY || time.Now().After(deadline) {
+				_, _ = logger.WriteString(fmt.Sprintf("DEBUG: syscall.Rmdir(%s) failed, err: %s\n", dir, err.Error()))
+				lastErr = err
+				break
+			}
+			// or some task forked while being killed
+			_ = cg.Kill()
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	return lastErr
+}
+
+// readCGroupStats parses flat keyed files like memory.oom_control, memory.events or cpu.stat.
+func readCGroupStats(path string) map[string]string {
+	res := make(map[string]string)
+
+	c, _ := ioutil.ReadFile(path)
+	rows := strings.Split(string(c), "\n")
+	for _, row := range rows {
+		if params := strings.Fields(row); len(params) == 2 {
+			res[params[0]] = params[1]
+		}
+	}
+
+	return res
+}
+
+// readCGroupInt reads single value files like pids.current.
+func readCGroupInt(path string) (int64, error) {
+	c, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(c)), 10, 64)
+}
+
+func writeCGroupFiles(dir string, files []cgroupFile) error {
+	for _, file := range files {
+		path := filepath.Join(dir, file.name)
+		if err := ioutil.WriteFile(path, []byte(file.value), 0644); err != nil {
+			_, _ = logger.WriteString(fmt.Sprintf("Writing [%s] to file: %s failed\n", file.value, path))
 			return err
 		}
 		c, _ := ioutil.ReadFile(path)
-		_, _ = os.Stderr.WriteString(fmt.Sprintf("DEBUG: Content of %s is: %s", path, c))
+		_, _ = logger.WriteString(fmt.Sprintf("DEBUG: Content of %s is: %s", path, c))
 	}
 	return nil
 }
+
+// https://www.kernel.org/doc/Documentation/cgroup-v1/cpusets.txt
+func cpusetCGroup(containerID string, limits *Limits) error {
+	return writeCGroupFiles(filepath.Join(cgCPUSetPathPrefix, containerID), []cgroupFile{
+		{"cpuset.mems", "0"},
+		{"cpuset.cpus", limits.CPUs},
+	})
+}
+
+// https://www.kernel.org/doc/Documentation/scheduler/sched-bwc.txt
+func cpuCGroup(containerID string, limits *Limits) error {
+	return writeCGroupFiles(filepath.Join(cgCPUPathPrefix, containerID), []cgroupFile{
+		{"cpu.cfs_period_us", strconv.FormatInt(limits.CPUPeriod, 10)},
+		{"cpu.cfs_quota_us", strconv.FormatInt(limits.CPUQuota, 10)},
+	})
+}
+
+// https://www.kernel.org/doc/Documentation/cgroup-v1/pids.txt
+func pidCGroup(containerID string, limits *Limits) error {
+	return writeCGroupFiles(filepath.Join(cgPidPathPrefix, containerID), []cgroupFile{
+		{"pids.max", strconv.FormatInt(limits.Pids, 10)},
+	})
+}
+
+// https://www.kernel.org/doc/Documentation/cgroup-v1/memory.txt
+func memoryCGroup(containerID string, limits *Limits) error {
+	dir := filepath.Join(cgMemoryPathPrefix, containerID)
+
+	var files []cgroupFile
+	// memory.kmem.limit_in_bytes is missing since linux 6.1, which charges kernel memory to memory.limit_in_bytes
+	if _, err := os.Stat(filepath.Join(dir, "memory.kmem.limit_in_bytes")); err == nil && limits.KMemory != -1 {
+		files = append(files, cgroupFile{"memory.kmem.limit_in_bytes", fmt.Sprintf("%dK", limits.KMemory)})
+	}
+	memory := cgroupFile{"memory.limit_in_bytes", fmt.Sprintf("%dK", limits.Memory)}
+	// memory.memsw.limit_in_bytes is missing if the kernel does not account swap at all
+	if _, err := os.Stat(filepath.Join(dir, "memory.memsw.limit_in_bytes")); err != nil {
+		return writeCGroupFiles(dir, append(files, memory))
+	}
+	memsw := cgroupFile{"memory.memsw.limit_in_bytes", fmt.Sprintf("%dK", limits.Memory+limits.Swap)}
+	if limits.Swap == -1 {
+		// no limit, as if it had never been written, e.g. by the run before of a pooled Sandbox
+		memsw.value = "-1"
+	}
+	// it must never be below memory.limit_in_bytes, so it is raised first when SetLimits raises or lifts the limit
+	if current, err := readCGroupInt(filepath.Join(dir, "memory.limit_in_bytes")); err == nil && (current < limits.Memory*1024 || limits.Swap == -1) {
+		return writeCGroupFiles(dir, append(files, memsw, memory))
+	}
+	return writeCGroupFiles(dir, append(files, memory, memsw))
+}
@@ -0,0 +1,66 @@
+// +build linux
+// +build go1.12
+
+package sandbox
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// all containers live under this cgroup, which only delegates controllers and never holds any process,
+// as required by the "no internal processes" rule of cgroup v2
+const cgV2PathPrefix = "/sys/fs/cgroup/justice/"
+
+const cgV2Controllers = "+cpuset +cpu +pids +memory"
+
+// https://www.kernel.org/doc/Documentation/cgroup-v2.txt
+func initCGroupV2(containerID string, limits *Limits) error {
+	if err := os.MkdirAll(cgV2PathPrefix, os.ModePerm); err != nil {
+		_, _ = logger.WriteString(fmt.Sprintf("DEBUG: os.MkdirAll(%s, os.ModePerm) failed, err: %s\n", cgV2PathPrefix, err.Error()))
+		return err
+	}
+
+	// enable the controllers for /sys/fs/cgroup/justice, then for its children
+	for _, dir := range []string{cgRootPath, cgV2PathPrefix} {
+		if err := writeCGroupFiles(dir, []cgroupFile{{"cgroup.subtree_control", cgV2Controllers}}); err != nil {
+			return err
+		}
+	}
+
+	dir := filepath.Join(cgV2PathPrefix, containerID)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		_, _ = logger.WriteString(fmt.Sprintf("DEBUG: os.MkdirAll(%s, os.ModePerm) failed, err: %s\n", dir, err.Error()))
+		return err
+	}
+	return limitCGroupV2(dir, limits)
+}
+
+// limitCGroupV2 writes limits to the control files of the cgroup dir.
+func limitCGroupV2(dir string, limits *Limits) error {
+	cpuMax := fmt.Sprintf("%d %d", limits.CPUQuota, limits.CPUPeriod)
+	if limits.CPUQuota == -1 {
+		cpuMax = fmt.Sprintf("max %d", limits.CPUPeriod)
+	}
+
+	files := []cgroupFile{
+		{"cpuset.mems", "0"},
+		{"cpuset.cpus", limits.CPUs},
+		{"cpu.max", cpuMax},
+		{"pids.max", strconv.FormatInt(limits.Pids, 10)},
+		// kernel memory is charged to memory.max as well, there is no separate kmem limit in v2
+		{"memory.max", strconv.FormatInt(limits.Memory*1024, 10)},
+	}
+	// memory.swap.max is missing if the kernel does not account swap at all
+	if _, err := os.Stat(filepath.Join(dir, "memory.swap.max")); err == nil {
+		swapMax := strconv.FormatInt(limits.Swap*1024, 10)
+		if limits.Swap == -1 {
+			swapMax = "max"
+		}
+		files = append(files, cgroupFile{"memory.swap.max", swapMax})
+	}
+
+	return writeCGroupFiles(dir, files)
+}
@@ -0,0 +1,254 @@
+// +build linux
+// +build go1.12
+
+package sandbox
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"strconv"
+)
+
+type CheckMode string
+
+const (
+	// byte by byte
+	CheckExact CheckMode = "exact"
+	// line by line, ignoring trailing whitespace of every line and trailing blank lines
+	CheckLines CheckMode = "lines"
+	// whitespace separated tokens
+	CheckTokens CheckMode = "tokens"
+	// tokens, where numbers are equal within AbsEpsilon or RelEpsilon
+	CheckFloat CheckMode = "float"
+)
+
+// endOfFile stands for the missing side of a Difference at the end of output or expected
+const endOfFile = "<EOF>"
+
+// at most this many bytes of output and expected are quoted in a Difference
+const differenceContext = 64
+
+// Judge sets the verdict of an OK result from the input, the output of the command and the expected output.
+// Results of any other verdict are left alone, as the output of a failed run is meaningless.
+type Judge interface {
+	Judge(result *Result, input, output, expected io.Reader)
+}
+
+// Checker compares the output of a command with the expected output.
+type Checker struct {
+	Mode       CheckMode
+	AbsEpsilon float64
+	RelEpsilon float64
+}
+
+// Difference is the first place the output differs from the expected output.
+// Line and Column are 1-based and point into the output.
+type Difference struct {
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// NewChecker validates mode and the epsilons of CheckFloat.
+//noinspection GoUnusedExportedFunction
+func NewChecker(mode CheckMode, absEpsilon, relEpsilon float64) (*Checker, error) {
+	switch mode {
+	case CheckExact, CheckLines, CheckTokens, CheckFloat:
+	default:
+		return nil, fmt.Errorf("unknown check mode: %s", mode)
+	}
+	if absEpsilon < 0 || relEpsilon < 0 {
+		return nil, fmt.Errorf("invalid epsilons %g, %g, must not be negative", absEpsilon, relEpsilon)
+	}
+	return &Checker{Mode: mode, AbsEpsilon: absEpsilon, RelEpsilon: relEpsilon}, nil
+}
+
+// Check returns the first Difference of output and expected, or nil if they are equal.
+func (c *Checker) Check(output, expected io.Reader) (*Difference, error) {
+	actual, err := ioutil.ReadAll(output)
+	if err != nil {
+		return nil, err
+	}
+	answer, err := ioutil.ReadAll(expected)
+	if err != nil {
+		return nil, err
+	}
+
+	switch c.Mode {
+	case CheckExact:
+		return checkExact(actual, answer), nil
+	case CheckLines:
+		return checkLines(actual, answer), nil
+	default:
+		return c.checkTokens(actual, answer), nil
+	}
+}
+
+// Judge sets the verdict of an OK result to VerdictAccepted or VerdictWrongAnswer, input is not needed.
+func (c *Checker) Judge(result *Result, _, output, expected io.Reader) {
+	if result.Verdict != VerdictOK {
+		return
+	}
+	difference, err := c.Check(output, expected)
+	switch {
+	case err != nil:
+		result.Verdict, result.Error = VerdictSystemError, err.Error()
+	case difference != nil:
+		result.Verdict, result.Difference = VerdictWrongAnswer, difference
+	default:
+		result.Verdict = VerdictAccepted
+	}
+}
+
+func checkExact(actual, answer []byte) *Difference {
+	i := commonPrefix(actual, answer)
+	if i == len(actual) && i == len(answer) {
+		return nil
+	}
+	line, column := position(actual, i)
+	difference := &Difference{Line: line, Column: column, Expected: endOfFile, Actual: endOfFile}
+	if i < len(answer) {
+		difference.Expected = quote(answer[i:])
+	}
+	if i < len(actual) {
+		difference.Actual = quote(actual[i:])
+	}
+	return difference
+}
+
+func checkLines(actual, answer []byte) *Difference {
+	actualLines, answerLines := trimLines(actual), trimLines(answer)
+	for i := 0; i < len(actualLines) || i < len(answerLines); i++ {
+		if i >= len(actualLines) {
+			return &Difference{Line: i + 1, Column: 1, Expected: quote(answerLines[i]), Actual: endOfFile}
+		}
+		if i >= len(answerLines) {
+			return &Difference{Line: i + 1, Column: 1, Expected: endOfFile, Actual: quote(actualLines[i])}
+		}
+		if j := commonPrefix(actualLines[i], answerLines[i]); j < len(actualLines[i]) || j < len(answerLines[i]) {
+			return &Difference{Line: i + 1, Column: j + 1, Expected: quote(answerLines[i][j:]), Actual: quote(actualLines[i][j:])}
+		}
+	}
+	return nil
+}
+
+// commonPrefix returns the length of the common prefix of a and b.
+func commonPrefix(a, b []byte) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// trimLines splits b into lines without trailing whitespace, dropping trailing blank lines.
+func trimLines(b []byte) [][]byte {
+	lines := bytes.Split(b, []byte("\n"))
+	for i := range lines {
+		lines[i] = bytes.TrimRight(lines[i], " \t\r\f\v")
+	}
+	for len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+type token struct {
+	text   []byte
+	line   int
+	column int
+}
+
+// tokenize splits b into whitespace separated tokens with their positions.
+func tokenize(b []byte) []token {
+	var tokens []token
+	line, column := 1, 1
+	for i := 0; i < len(b); {
+		if isSpace(b[i]) {
+			if b[i] == '\n' {
+				line, column = line+1, 1
+			} else {
+				column++
+			}
+			i++
+			continue
+		}
+		start := i
+		for i < len(b) && !isSpace(b[i]) {
+			i++
+		}
+		tokens = append(tokens, token{text: b[start:i], line: line, column: column})
+		column += i - start
+	}
+	return tokens
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+func (c *Checker) checkTokens(actual, answer []byte) *Difference {
+	actualTokens, answerTokens := tokenize(actual), tokenize(answer)
+	for i := 0; i < len(actualTokens) || i < len(answerTokens); i++ {
+		if i >= len(actualTokens) {
+			line, column := position(actual, len(actual))
+			return &Difference{Line: line, Column: column, Expected: quote(answerTokens[i].text), Actual: endOfFile}
+		}
+		t := actualTokens[i]
+		if i >= len(answerTokens) {
+			return &Difference{Line: t.line, Column: t.column, Expected: endOfFile, Actual: quote(t.text)}
+		}
+		if !c.tokenEqual(t.text, answerTokens[i].text) {
+			return &Difference{Line: t.line, Column: t.column, Expected: quote(answerTokens[i].text), Actual: quote(t.text)}
+		}
+	}
+	return nil
+}
+
+func (c *Checker) tokenEqual(actual, answer []byte) bool {
+	if bytes.Equal(actual, answer) {
+		return true
+	}
+	if c.Mode != CheckFloat {
+		return false
+	}
+
+	x, err := strconv.ParseFloat(string(actual), 64)
+	if err != nil {
+		return false
+	}
+	y, err := strconv.ParseFloat(string(answer), 64)
+	if err != nil {
+		return false
+	}
+	if math.IsNaN(x) || math.IsNaN(y) {
+		return false
+	}
+	if math.IsInf(x, 0) || math.IsInf(y, 0) {
+		return x == y
+	}
+	delta := math.Abs(x - y)
+	return delta <= c.AbsEpsilon || delta <= c.RelEpsilon*math.Abs(y)
+}
+
+// position returns the 1-based line and column of offset in b.
+func position(b []byte, offset int) (int, int) {
+	line := bytes.Count(b[:offset], []byte("\n")) + 1
+	column := offset - bytes.LastIndexByte(b[:offset], '\n')
+	return line, column
+}
+
+// quote returns the rest of the line at the start of b, at most differenceContext bytes of it.
+func quote(b []byte) string {
+	if i := bytes.IndexByte(b, '\n'); i >= 0 {
+		b = b[:i]
+	}
+	if len(b) > differenceContext {
+		b = b[:differenceContext]
+	}
+	return string(b)
+}
@@ -0,0 +1,258 @@
+// +build linux
+// +build go1.12
+
+package sandbox
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// in an entry of the cache, beside the files written by the compiler
+	cacheResult = "result.json"
+	cacheFiles  = "files"
+	// prefix of the entries being written
+	cacheTempPrefix = "tmp-"
+)
+
+// CompileCache is a content-addressed cache of compilations on local disk, see CompileConfig.Cache.
+//
+// An entry is a directory named by the key of the compilation, holding its CompileResult and the files written by
+// the compiler. The entries least recently used are evicted once they take more than the max size. Entries are
+// written to a temporary directory first and renamed, so that compilers of several processes may share a cache.
+type CompileCache struct {
+	dir     string
+	maxSize int64
+
+	mutex sync.Mutex
+	// hash of the executable of a compiler by path, size and modification time
+	compilers map[string]string
+}
+
+// NewCompileCache returns the cache in dir, which is created if missing, taking at most maxSize bytes.
+//noinspection GoUnusedExportedFunction
+func NewCompileCache(dir string, maxSize int64) (*CompileCache, error) {
+	if maxSize <= 0 {
+		return nil, fmt.Errorf("invalid size %d of the compile cache, must be positive", maxSize)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &CompileCache{dir: dir, maxSize: maxSize, compilers: make(map[string]string)}, nil
+}
+
+// key hashes everything the outcome of the compilation depends on: the sources, the files of the problem, the
+// executable of the compiler, its arguments, environment and toolchain, and the limits.
+func (c *CompileCache) key(cfg *CompileConfig, runCfg *Config) (string, error) {
+	compiler, err := c.compilerHash(cfg.Compiler)
+	if err != nil {
+		return "", err
+	}
+	var inputs, includeDirs []string
+	var paths []string
+	for _, name := range cfg.sources() {
+		paths = append(paths, filepath.Join(cfg.BaseDir, name))
+	}
+	for _, path := range append(paths, cfg.Link...) {
+		hash, err := fileHash(path)
+		if err != nil {
+			return "", err
+		}
+		inputs = append(inputs, hash)
+	}
+	for _, dir := range cfg.IncludeDirs {
+		hash, err := treeHash(dir)
+		if err != nil {
+			return "", err
+		}
+		includeDirs = append(includeDirs, hash)
+	}
+	b, _ := json.Marshal(struct {
+		Inputs, IncludeDirs, ForbiddenHeaders []string
+		Compiler, CompilerHash                string
+		Args, Env, Toolchain                  []string
+		Timeout, Memory, Pids                 int64
+		FileSize, StdoutLimit, StderrLimit    int64
+	}{
+		inputs, includeDirs, cfg.ForbiddenHeaders,
+		cfg.Compiler, compiler,
+		runCfg.Args, runCfg.Env, cfg.Toolchain,
+		cfg.Timeout, cfg.Memory, runCfg.Limits.Pids,
+		runCfg.FileSize, runCfg.StdoutLimit, runCfg.StderrLimit,
+	})
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// compilerHash identifies the version of the compiler by the hash of its executable, which is only hashed again
+// once it changed on disk, e.g. by an upgrade.
+func (c *CompileCache) compilerHash(compiler string) (string, error) {
+	path, err := filepath.EvalSymlinks(compiler)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	id := fmt.Sprintf("%s:%d:%d", path, info.Size(), info.ModTime().UnixNano())
+
+	c.mutex.Lock()
+	hash, ok := c.compilers[id]
+	c.mutex.Unlock()
+	if ok {
+		return hash, nil
+	}
+	if hash, err = fileHash(path); err != nil {
+		return "", err
+	}
+	c.mutex.Lock()
+	c.compilers[id] = hash
+	c.mutex.Unlock()
+	return hash, nil
+}
+
+// get copies the files of the entry key into baseDir and returns its result, false if there is no such entry.
+func (c *CompileCache) get(key, baseDir string) (*CompileResult, bool) {
+	entry := filepath.Join(c.dir, key)
+	b, err := ioutil.ReadFile(filepath.Join(entry, cacheResult))
+	if err != nil {
+		return nil, false
+	}
+	r := &CompileResult{}
+	if err := json.Unmarshal(b, r); err != nil {
+		return nil, false
+	}
+	// the entry may be evicted meanwhile by another process, then it is a miss
+	if r.OK {
+		if err := copyCompiled(filepath.Join(entry, cacheFiles), baseDir, nil, nil); err != nil {
+			_, _ = logger.WriteString(fmt.Sprintf("copy of compile cache entry %s failed, err: %s\n", key, err.Error()))
+			return nil, false
+		}
+	}
+	now := time.Now()
+	_ = os.Chtimes(entry, now, now)
+	r.Cached = true
+	return r, true
+}
+
+// put adds the entry key of r and the artifacts written by the compiler into workDir, then evicts the entries least
+// recently used beyond the max size.
+func (c *CompileCache) put(key string, r *CompileResult, workDir string, artifacts, inputs []string) error {
+	tmp, err := ioutil.TempDir(c.dir, cacheTempPrefix)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := os.Mkdir(filepath.Join(tmp, cacheFiles), 0700); err != nil {
+		return err
+	}
+	if r.OK {
+		if err := copyCompiled(workDir, filepath.Join(tmp, cacheFiles), artifacts, inputs); err != nil {
+			return err
+		}
+	}
+	b, _ := json.Marshal(r)
+	if err := ioutil.WriteFile(filepath.Join(tmp, cacheResult), b, 0600); err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry := filepath.Join(c.dir, key)
+	// a stale entry refreshed by CompileConfig.NoCache
+	_ = os.RemoveAll(entry)
+	if err := os.Rename(tmp, entry); err != nil {
+		return err
+	}
+	return c.evict()
+}
+
+// evict removes the entries least recently used until the others take at most the max size.
+func (c *CompileCache) evict() error {
+	infos, err := ioutil.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+	type entry struct {
+		name string
+		used time.Time
+		size int64
+	}
+	var entries []entry
+	var total int64
+	for _, info := range infos {
+		if !info.IsDir() || strings.HasPrefix(info.Name(), cacheTempPrefix) {
+			continue
+		}
+		e := entry{name: filepath.Join(c.dir, info.Name()), used: info.ModTime()}
+		_ = filepath.Walk(e.name, func(_ string, info os.FileInfo, err error) error {
+			if err == nil && info.Mode().IsRegular() {
+				e.size += info.Size()
+			}
+			return nil
+		})
+		entries = append(entries, e)
+		total += e.size
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].used.Before(entries[j].used)
+	})
+	for _, e := range entries {
+		if total <= c.maxSize {
+			break
+		}
+		if err := os.RemoveAll(e.name); err != nil {
+			return err
+		}
+		total -= e.size
+	}
+	return nil
+}
+
+// treeHash returns the hex sha256 of the names and contents of the regular files under dir.
+func treeHash(dir string) (string, error) {
+	h := sha256.New()
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		hash, err := fileHash(path)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(h, "%s %s\n", strings.TrimPrefix(path, dir), hash)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// fileHash returns the hex sha256 of the content of the regular file name, see openRegular.
+func fileHash(name string) (string, error) {
+	f, err := openRegular(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,573 @@
+// +build linux
+// +build go1.12
+
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// limits of language profiles without any
+	compileTimeout = 5000
+	compileMemory  = 256 * 1024
+	// the compiler, its children and ld fit into the pids limit, a fork bomb in a plugin does not
+	compilePids = 32
+	// KB, the preprocessed source of an include bomb or a binary with a huge initialized array exceeds the file size
+	compileFileSize    = 64 * 1024
+	compileOutputLimit = 256
+)
+
+// CompileConfig describes the compilation of Filename in BaseDir by the profile of Lang,
+// zero fields are those of the profile.
+type CompileConfig struct {
+	// name of the LanguageProfile, c if empty
+	Lang string `json:"lang"`
+	// compiler with abs path
+	Compiler string `json:"compiler"`
+	BaseDir  string `json:"basedir"`
+	Filename string `json:"filename"`
+	// language standard supported by the compiler, e.g. gnu11
+	Std string `json:"std"`
+	// timeout in milliseconds
+	Timeout int64 `json:"timeout"`
+	// memory limit in KB of the compiler and all its children
+	Memory int64 `json:"memory"`
+	// max size in KB of every file written by the compiler, e.g. the binary or the preprocessed source
+	FileSize int64 `json:"fileSize"`
+	// max size in KB of stdout and of stderr of the compiler
+	OutputLimit int64 `json:"outputLimit"`
+	// host user the compiler runs as
+	Username string `json:"username"`
+	// host directories of the toolchain, mounted read-only at the same path, symlinks are copied as they are
+	Toolchain []string `json:"toolchain"`
+	// names of the other files of a multi-file submission in BaseDir, compiled with Filename, see LanguageProfile.Args
+	Sources []string `json:"sources"`
+	// host directories of headers, e.g. of a grader, mounted read-only and searched before those of the toolchain
+	IncludeDirs []string `json:"includeDirs"`
+	// host files copied beside the sources and appended to the arguments of the compiler, e.g. grader.c or grader.o
+	Link []string `json:"link"`
+	// directory of the files of the problems, IncludeDirs and Link are relative to or below it and are opened with
+	// the credentials of Username, neither is allowed if empty
+	ProblemDir HostDir `json:"-"`
+	// flags of the compiler after the sources and the files to link, e.g. -O2 or -lm, see LanguageProfile.AllowedFlags
+	Flags []string `json:"flags"`
+	// headers Filename and Sources must not include, e.g. bits/stdc++.h, see checkIncludes and checkDependencies
+	ForbiddenHeaders []string `json:"forbiddenHeaders"`
+	// reuses the outcome of an identical compilation if not nil
+	Cache *CompileCache `json:"-"`
+	// compiles even if Cache holds the outcome, which is replaced
+	NoCache bool `json:"noCache"`
+}
+
+// DefaultCompileConfig returns the defaults of the flags of clike_compiler.
+//noinspection GoUnusedExportedFunction
+func DefaultCompileConfig() *CompileConfig {
+	return &CompileConfig{
+		Lang:     "c",
+		BaseDir:  "/tmp",
+		Username: "nobody",
+		// /etc/alternatives resolves e.g. /usr/bin/cc and /usr/bin/javac
+		Toolchain: []string{"/usr", "/bin", "/sbin", "/lib", "/lib32", "/lib64", "/libx32", "/etc/alternatives"},
+	}
+}
+
+// withProfile returns a copy of c with the zero fields filled in by its language profile.
+func (c *CompileConfig) withProfile() (*CompileConfig, *LanguageProfile, error) {
+	lang := c.Lang
+	if lang == "" {
+		lang = "c"
+	}
+	profile, err := LookupLanguageProfile(lang)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cfg := *c
+	cfg.Lang = lang
+	if cfg.Compiler == "" {
+		cfg.Compiler = profile.Compiler
+	}
+	if cfg.Filename == "" {
+		cfg.Filename = profile.Source
+	}
+	if cfg.Std == "" {
+		cfg.Std = profile.Std
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = profile.Timeout
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = compileTimeout
+	}
+	if cfg.Memory == 0 {
+		cfg.Memory = profile.Memory
+	}
+	if cfg.Memory == 0 {
+		cfg.Memory = compileMemory
+	}
+	if cfg.FileSize == 0 {
+		cfg.FileSize = compileFileSize
+	}
+	if cfg.OutputLimit == 0 {
+		cfg.OutputLimit = compileOutputLimit
+	}
+	if cfg.FileSize < 0 || cfg.OutputLimit < 0 {
+		return nil, nil, fmt.Errorf("invalid file size %d or output limit %d, must not be negative", cfg.FileSize, cfg.OutputLimit)
+	}
+	cfg.Toolchain = append(append([]string{}, c.Toolchain...), profile.Toolchain...)
+	if err := cfg.validateFiles(profile); err != nil {
+		return nil, nil, err
+	}
+	return &cfg, profile, nil
+}
+
+// validateFiles checks that the files of the submission are plain names in BaseDir, which cannot escape the scratch
+// directory of the compiler, and resolves the files of the problem below ProblemDir.
+func (c *CompileConfig) validateFiles(profile *LanguageProfile) error {
+	names := make(map[string]bool)
+	addName := func(name string) error {
+		if name == "" || name == "." || name == ".." || filepath.Base(name) != name {
+			return fmt.Errorf("invalid source %q, must be a file name", name)
+		}
+		if names[name] {
+			return fmt.Errorf("duplicate source %s", name)
+		}
+		names[name] = true
+		return nil
+	}
+	for _, name := range append([]string{c.Filename}, c.Sources...) {
+		if err := addName(name); err != nil {
+			return err
+		}
+	}
+	link, includeDirs := make([]string, len(c.Link)), make([]string, len(c.IncludeDirs))
+	for i, name := range c.Link {
+		path, err := c.ProblemDir.Resolve(name)
+		if err != nil {
+			return fmt.Errorf("invalid link %q, err: %s", name, err.Error())
+		}
+		if err := addName(filepath.Base(path)); err != nil {
+			return err
+		}
+		link[i] = path
+	}
+	for i, dir := range c.IncludeDirs {
+		path, err := c.ProblemDir.Resolve(dir)
+		if err != nil {
+			return fmt.Errorf("invalid include dir %q, err: %s", dir, err.Error())
+		}
+		includeDirs[i] = path
+	}
+	c.Link, c.IncludeDirs = link, includeDirs
+	for _, header := range c.ForbiddenHeaders {
+		if header == "" || filepath.IsAbs(header) || strings.HasPrefix(filepath.Clean(header), "..") {
+			return fmt.Errorf("invalid forbidden header %q", header)
+		}
+	}
+	if (len(c.IncludeDirs) > 0 || len(c.ForbiddenHeaders) > 0) && profile.Include == "" {
+		return fmt.Errorf("language %s has no include directories", profile.Name)
+	}
+	if len(c.ForbiddenHeaders) > 0 && len(profile.Dependencies) == 0 {
+		return fmt.Errorf("language %s lists no dependencies to check the forbidden headers against", profile.Name)
+	}
+	for _, flag := range c.Flags {
+		if err := profile.checkFlag(flag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sources returns the names of the submission in the scratch directory, Filename first.
+func (c *CompileConfig) sources() []string {
+	return append([]string{c.Filename}, c.Sources...)
+}
+
+// inputs returns the names of every file copied into the scratch directory, which are not copied back.
+func (c *CompileConfig) inputs() []string {
+	inputs := c.sources()
+	for _, name := range c.Link {
+		inputs = append(inputs, filepath.Base(name))
+	}
+	return inputs
+}
+
+// args returns the arguments of the compiler of profile, the include directories first and the files to link and
+// the flags last.
+func (c *CompileConfig) args(profile *LanguageProfile) []string {
+	args := append(c.includeArgs(profile), replacePlaceholders(profile.Args, c.sources(), profile.Output, c.Std)...)
+	for _, name := range c.Link {
+		args = append(args, filepath.Base(name))
+	}
+	return append(args, c.Flags...)
+}
+
+// dependencyArgs returns the arguments of the compiler of profile listing the headers of the sources, see args.
+func (c *CompileConfig) dependencyArgs(profile *LanguageProfile) []string {
+	args := append(c.includeArgs(profile), replacePlaceholders(profile.Dependencies, c.sources(), profile.Output, c.Std)...)
+	return append(args, c.Flags...)
+}
+
+// includeArgs returns the arguments of the compiler of profile searching the shadows of the forbidden headers first
+// and the include directories next.
+func (c *CompileConfig) includeArgs(profile *LanguageProfile) []string {
+	var args []string
+	if len(c.ForbiddenHeaders) > 0 {
+		args = append(args, profile.Include+forbiddenHeadersDir)
+	}
+	for i := range c.IncludeDirs {
+		args = append(args, profile.Include+includeDir(i))
+	}
+	return args
+}
+
+// limits of a compilation reported by CompileResult.Limit
+const (
+	compileLimitTime     = "time"
+	compileLimitMemory   = "memory"
+	compileLimitOutput   = "output"
+	compileLimitFileSize = "fileSize"
+)
+
+// CompileResult is the machine-readable record of a compilation, times are in milliseconds and memory is in KB.
+type CompileResult struct {
+	OK bool `json:"ok"`
+	// VerdictOK, VerdictCompileError, VerdictCompileLimitExceeded, or VerdictSystemError if the compiler did not run
+	Verdict Verdict `json:"verdict"`
+	// time, memory, output or fileSize with VerdictCompileLimitExceeded
+	Limit    string `json:"limit,omitempty"`
+	ExitCode int    `json:"exitCode"`
+	// the time limit killed the compiler
+	Timeout  bool  `json:"timeout"`
+	CPUTime  int64 `json:"cpuTime"`
+	WallTime int64 `json:"wallTime"`
+	Memory   int64 `json:"memory"`
+	// parsed from Output, see ParseDiagnostics
+	Diagnostics []Diagnostic `json:"diagnostics"`
+	// stdout and stderr of the compiler, diagnostics written as json are rendered as text, see readableOutput
+	Output string `json:"output"`
+	// the error of Compile, empty if OK
+	Error string `json:"error,omitempty"`
+	// taken from CompileConfig.Cache rather than compiled
+	Cached bool `json:"cached"`
+}
+
+// Compile runs the compiler in a container of its own until it exits, cfg.Timeout passes or ctx is done.
+//
+// The root filesystem of the container only holds the toolchain and the include directories, mounted read-only, some
+// devices and a scratch directory with a copy of the sources and the files to link, which the files written by the
+// compiler are copied back from into BaseDir.
+// The error carries stderr of the compiler, it starts with "Compile Limit Exceeded" if a limit killed the compiler.
+//noinspection GoUnusedExportedFunction
+func Compile(ctx context.Context, cfg *CompileConfig) error {
+	if r := CompileWithResult(ctx, cfg); !r.OK {
+		return errors.New(r.Error)
+	}
+	return nil
+}
+
+// CompileWithResult is Compile, which reports the diagnostics and the resource usage of the compiler as well.
+//noinspection GoUnusedExportedFunction
+func CompileWithResult(ctx context.Context, cfg *CompileConfig) *CompileResult {
+	r := &CompileResult{Verdict: VerdictSystemError, Diagnostics: make([]Diagnostic, 0)}
+	fail := func(err error) *CompileResult {
+		r.OK, r.Error = false, err.Error()
+		return r
+	}
+	compileError := func(err error) *CompileResult {
+		r.Verdict = VerdictCompileError
+		return fail(err)
+	}
+	limitExceeded := func(limit, format string, value int64) *CompileResult {
+		r.Verdict, r.Limit = VerdictCompileLimitExceeded, limit
+		return fail(fmt.Errorf("Compile Limit Exceeded: "+format+", stderr: %s", value, r.Output))
+	}
+
+	cfg, profile, err := cfg.withProfile()
+	if err != nil {
+		return fail(err)
+	}
+	// an interpreted language, see LanguageProfile.Run
+	if cfg.Compiler == "" {
+		r.OK, r.Verdict = true, VerdictOK
+		return r
+	}
+	forbiddenIncludes := func(diagnostics []Diagnostic) *CompileResult {
+		r.Output, r.Diagnostics = formatDiagnostics(diagnostics), diagnostics
+		return compileError(fmt.Errorf("stderr: %s, err: forbidden include", r.Output))
+	}
+	if len(cfg.ForbiddenHeaders) > 0 {
+		diagnostics, err := checkIncludes(cfg.BaseDir, cfg.sources())
+		if err != nil {
+			return fail(err)
+		}
+		if len(diagnostics) > 0 {
+			return forbiddenIncludes(diagnostics)
+		}
+	}
+	limits := DefaultLimits()
+	limits.Memory, limits.Pids, limits.CPUQuota = cfg.Memory, profile.Pids, -1
+	if limits.Pids == 0 {
+		limits.Pids = compilePids
+	}
+	runCfg := &Config{
+		Command: cfg.Compiler,
+		Args:    cfg.args(profile),
+		Env:     append([]string{"PATH=/usr/local/bin:/usr/bin:/bin", "TMPDIR=" + rootfsWorkDir}, profile.Env...),
+		WorkDir: rootfsWorkDir,
+		Timeout: cfg.Timeout,
+		Limits:  limits,
+		// the compiler is killed once its output exceeds the limit, so that the buffer below is bounded
+		StdoutLimit: cfg.OutputLimit,
+		StderrLimit: cfg.OutputLimit,
+		FileSize:    cfg.FileSize,
+		Quiet:       true,
+	}
+
+	u, err := user.Lookup(cfg.Username)
+	if err != nil {
+		return fail(err)
+	}
+	uid, _ := strconv.Atoi(u.Uid)
+	gid, _ := strconv.Atoi(u.Gid)
+	// neither the cache nor the compiler may reveal files of the problem the user cannot read
+	if err := cfg.checkProblemFiles(uid, gid); err != nil {
+		return fail(err)
+	}
+
+	var key string
+	if cfg.Cache != nil {
+		if key, err = cfg.Cache.key(cfg, runCfg); err != nil {
+			return fail(err)
+		}
+		if !cfg.NoCache {
+			if cached, ok := cfg.Cache.get(key, cfg.BaseDir); ok {
+				return cached
+			}
+		}
+	}
+
+	rootfs, mounts, err := newCompileRootfs(cfg, uid, gid)
+	if err != nil {
+		return fail(err)
+	}
+	defer os.RemoveAll(rootfs)
+	workDir := filepath.Join(rootfs, rootfsWorkDir)
+
+	// only the outcomes of the source are cached, not those of the limits or of ctx
+	store := func(r *CompileResult) *CompileResult {
+		if cfg.Cache == nil {
+			return r
+		}
+		if err := cfg.Cache.put(key, r, workDir, profile.artifacts(), cfg.inputs()); err != nil {
+			_, _ = logger.WriteString(fmt.Sprintf("compile cache put of %s failed, err: %s\n", key, err.Error()))
+		}
+		return r
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	runCfg.BaseDir, runCfg.Mounts, runCfg.UID, runCfg.GID = rootfs, mounts, uid, gid
+	run := func(runCfg *Config, stdout, stderr io.Writer) *Result {
+		// the cgroup is destroyed once Run returns
+		finished := make(chan struct{})
+		defer close(finished)
+		runCfg.Started = func(cg *CGroup) {
+			go func() {
+				select {
+				case <-ctx.Done():
+					_ = cg.Kill()
+				case <-finished:
+				}
+			}()
+		}
+		return Run(runCfg, nil, stdout, stderr)
+	}
+
+	// the headers are listed by a run of the compiler of its own, which fails just like the compilation if the
+	// sources do not preprocess
+	var dependenciesErr error
+	if len(cfg.ForbiddenHeaders) > 0 {
+		dependenciesCfg := *runCfg
+		dependenciesCfg.Args = cfg.dependencyArgs(profile)
+		var rules, stderr bytes.Buffer
+		if result := run(&dependenciesCfg, &rules, &stderr); result.Verdict != VerdictOK {
+			dependenciesErr = fmt.Errorf("stderr: %s, err: no headers listed, %s", stderr.String(), result.Error)
+		} else if diagnostics := checkDependencies(rules.Bytes(), cfg.IncludeDirs, cfg.ForbiddenHeaders); len(diagnostics) > 0 {
+			return store(forbiddenIncludes(diagnostics))
+		}
+	}
+
+	// some compilers, e.g. fpc, write their diagnostics to stdout
+	var stderr bytes.Buffer
+	result := run(runCfg, &stderr, &stderr)
+	r.ExitCode, r.Timeout = result.ExitCode, result.Verdict == VerdictTimeLimitExceeded
+	r.CPUTime, r.WallTime, r.Memory = result.CPUTime, result.WallTime, result.Memory
+	r.Output, r.Diagnostics = readableOutput(stderr.Bytes()), ParseDiagnostics(stderr.Bytes())
+	switch {
+	case result.Verdict == VerdictOK && dependenciesErr != nil:
+		return fail(dependenciesErr)
+	case result.Verdict == VerdictOK:
+		if _, err := os.Stat(filepath.Join(workDir, profile.Output)); err != nil {
+			return store(compileError(fmt.Errorf("stderr: %s, err: no %s written by %s", r.Output, profile.Output, cfg.Compiler)))
+		}
+		if err := copyCompiled(workDir, cfg.BaseDir, profile.artifacts(), cfg.inputs()); err != nil {
+			return fail(err)
+		}
+		r.OK, r.Verdict = true, VerdictOK
+		return store(r)
+	case ctx.Err() != nil:
+		return fail(fmt.Errorf("stderr: %s, err: %s", r.Output, ctx.Err().Error()))
+	case result.Verdict == VerdictTimeLimitExceeded:
+		return limitExceeded(compileLimitTime, "time limit of %dms", cfg.Timeout)
+	case result.Verdict == VerdictMemoryLimitExceeded:
+		return limitExceeded(compileLimitMemory, "memory limit of %dKB", cfg.Memory)
+	case fileSizeExceeded(workDir, cfg.FileSize*1024, profile, result, r.Output):
+		return limitExceeded(compileLimitFileSize, "file size limit of %dKB", cfg.FileSize)
+	case result.Verdict == VerdictOutputLimitExceeded:
+		return limitExceeded(compileLimitOutput, "output limit of %dKB", cfg.OutputLimit)
+	case result.Verdict == VerdictRuntimeError:
+		return store(compileError(fmt.Errorf("stderr: %s, err: %s", r.Output, result.Error)))
+	}
+	return fail(fmt.Errorf("stderr: %s, err: %s", r.Output, result.Error))
+}
+
+// fileSizeExceeded returns whether RLIMIT_FSIZE stopped a writer of the compiler of profile, i.e. a file in workDir
+// reached size, or SIGXFSZ killed a tool writing beyond the limit at an offset, e.g. as or ld, which leaves a smaller
+// file. The output reporting the signal is controlled by the submission as well, e.g. by #error or #line, so it is only
+// believed along with what the submission cannot fake: gcc exits with 4 once a signal killed cc1 or as, and a linker
+// killed by a signal leaves Output behind, which it removes on errors.
+func fileSizeExceeded(workDir string, size int64, profile *LanguageProfile, result *Result, output string) bool {
+	if strings.Contains(output, "File size limit exceeded") {
+		if _, err := os.Stat(filepath.Join(workDir, profile.Output)); result.ExitCode == 4 || err == nil {
+			return true
+		}
+	}
+	files, err := ioutil.ReadDir(workDir)
+	if err != nil {
+		return false
+	}
+	for _, info := range files {
+		if info.Mode().IsRegular() && info.Size() >= size {
+			return true
+		}
+	}
+	return false
+}
+
+// checkProblemFiles checks that uid and gid may read the files to link and the include directories.
+func (c *CompileConfig) checkProblemFiles(uid, gid int) error {
+	for _, path := range append(append([]string{}, c.Link...), c.IncludeDirs...) {
+		f, err := openAs(path, uid, gid)
+		if err != nil {
+			return err
+		}
+		_ = f.Close()
+	}
+	return nil
+}
+
+// newCompileRootfs creates the root filesystem of the compiler, which is owned by root and thus read-only to it,
+// with a scratch directory owned by uid holding a copy of the sources and the files to link, which are read with the
+// credentials of uid and gid.
+func newCompileRootfs(cfg *CompileConfig, uid, gid int) (string, []Mount, error) {
+	rootfs, mounts, err := newRootfs("justice-compile-", cfg.Toolchain)
+	if err != nil {
+		return "", nil, err
+	}
+	fail := func(err error) (string, []Mount, error) {
+		_ = os.RemoveAll(rootfs)
+		return "", nil, err
+	}
+
+	workDir := filepath.Join(rootfs, rootfsWorkDir)
+	if err := os.Mkdir(workDir, 0755); err != nil {
+		return fail(err)
+	}
+	if err := os.Chown(workDir, uid, gid); err != nil {
+		return fail(err)
+	}
+	// the sources are plain names in BaseDir, the files of the problem are read as the user of the compiler
+	open := make(map[string]func() (*os.File, error))
+	for _, name := range cfg.sources() {
+		path := filepath.Join(cfg.BaseDir, name)
+		open[name] = func() (*os.File, error) {
+			return openRegular(path)
+		}
+	}
+	for _, path := range cfg.Link {
+		path := path
+		open[filepath.Base(path)] = func() (*os.File, error) {
+			return openAs(path, uid, gid)
+		}
+	}
+	for name, openFile := range open {
+		f, err := openFile()
+		if err != nil {
+			return fail(err)
+		}
+		err = copyFile(filepath.Join(workDir, name), f, 0644)
+		_ = f.Close()
+		if err != nil {
+			return fail(err)
+		}
+	}
+
+	if err := writeForbiddenHeaders(rootfs, cfg.ForbiddenHeaders); err != nil {
+		return fail(err)
+	}
+	for i, dir := range cfg.IncludeDirs {
+		mounts = append(mounts, Mount{Source: dir, Target: includeDir(i), ReadOnly: true})
+	}
+	return rootfs, mounts, nil
+}
+
+// copyCompiled copies the files matching one of the patterns of artifacts but the inputs from the scratch directory
+// into baseDir, every file if artifacts is nil.
+func copyCompiled(workDir, baseDir string, artifacts, inputs []string) error {
+	skip := make(map[string]bool)
+	for _, name := range inputs {
+		skip[name] = true
+	}
+	isArtifact := func(name string) bool {
+		for _, pattern := range artifacts {
+			if ok, _ := filepath.Match(pattern, name); ok {
+				return true
+			}
+		}
+		return artifacts == nil
+	}
+	files, err := ioutil.ReadDir(workDir)
+	if err != nil {
+		return err
+	}
+	for _, info := range files {
+		if !info.Mode().IsRegular() || skip[info.Name()] || !isArtifact(info.Name()) {
+			continue
+		}
+		f, err := os.Open(filepath.Join(workDir, info.Name()))
+		if err != nil {
+			return err
+		}
+		name := filepath.Join(baseDir, info.Name())
+		_ = os.Remove(name)
+		err = copyFile(name, f, info.Mode().Perm())
+		_ = f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,522 @@
+// +build linux
+// +build go1.12
+
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/docker/docker/pkg/reexec"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	// justiceInit reports its Result to Run() through this fd, i.e. cmd.ExtraFiles[0]
+	resultFd = 3
+	// Run() writes the Config as json to this fd, i.e. cmd.ExtraFiles[1], and closes it once justiceInit is in the cgroup
+	startFd = 4
+	// justiceExec reports failures before execve() to justiceInit through this fd
+	execErrFd = 3
+	// justiceInit writes the Config as json to justiceExec through this fd
+	execConfigFd = 4
+
+	cpuTimePollInterval = 10 * time.Millisecond
+
+	// the only environment of the command besides Config.Env
+	commandPS1 = "PS1=[justice] # "
+)
+
+func init() {
+	// binaries calling Run() must call reexec.Init() first thing in their own init()
+	reexec.Register("justiceInit", justiceInit)
+	reexec.Register("justiceExec", justiceExec)
+}
+
+// Config describes one run of a command in a new container.
+type Config struct {
+	// id of the container and its cgroup, a random uuid if empty
+	ID      string
+	BaseDir string
+	Command string
+	// arguments of Command, not including Command itself
+	Args []string
+	// environment of Command in the form key=value
+	Env []string
+	// host paths mounted read-only in a new root filesystem, e.g. the runtime of an interpreter,
+	// BaseDir is mounted at WorkDir then instead of becoming the root filesystem
+	Rootfs []string
+	// host paths bind mounted below the root filesystem
+	Mounts []Mount
+	// working directory of Command inside the container, / if empty, or /code if Rootfs is not empty
+	WorkDir string
+	// wall clock limit in milliseconds
+	Timeout int64
+	// cpu time limit in milliseconds of all processes and threads, 0 for Timeout only
+	CPUTime int64
+	Limits  *Limits
+	// name of the seccomp profile, see LoadSeccompProfile
+	Seccomp string
+	// limits in KB of stdout, stderr and of every file written by the command, 0 for no limit
+	StdoutLimit int64
+	StderrLimit int64
+	FileSize    int64
+	// host uid and gid the command runs as
+	UID int
+	GID int
+	// discard the logs of the sandbox, which would otherwise be mixed into stderr of the command
+	Quiet bool
+	// called with the cgroup once the command is started in it, e.g. to watch it alongside Run
+	Started func(cg *CGroup) `json:"-"`
+}
+
+// Validate checks the limits of c, Run reports the error as VerdictSystemError.
+func (c *Config) Validate() error {
+	if c.Limits == nil {
+		return fmt.Errorf("no limits")
+	}
+	if _, err := LoadSeccompProfile(c.Seccomp); err != nil {
+		return err
+	}
+	if err := c.Limits.Validate(); err != nil {
+		return err
+	}
+	if c.Timeout <= 0 || c.CPUTime < 0 {
+		return fmt.Errorf("invalid time limits %d, %d", c.Timeout, c.CPUTime)
+	}
+	if c.StdoutLimit < 0 || c.StderrLimit < 0 || c.FileSize < 0 {
+		return fmt.Errorf("invalid output limits %d, %d, %d, must not be negative", c.StdoutLimit, c.StderrLimit, c.FileSize)
+	}
+	for _, env := range c.Env {
+		if strings.IndexByte(env, '=') <= 0 {
+			return fmt.Errorf("invalid env %q, must be key=value", env)
+		}
+	}
+	return nil
+}
+
+// Run executes cfg.Command in new namespaces and a new cgroup, which is destroyed before Run returns.
+// Every failure of the sandbox itself is reported as a Result with VerdictSystemError.
+//noinspection GoUnusedExportedFunction
+func Run(cfg *Config, stdin io.Reader, stdout, stderr io.Writer) *Result {
+	systemError := func(err error) *Result {
+		return &Result{Verdict: VerdictSystemError, Error: err.Error()}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return systemError(err)
+	}
+
+	baseDir, mounts, workDir := cfg.BaseDir, cfg.Mounts, cfg.WorkDir
+	if len(cfg.Rootfs) > 0 {
+		rootfs, rootfsMounts, err := newRootfs("justice-run-", cfg.Rootfs)
+		if err != nil {
+			return systemError(err)
+		}
+		defer os.RemoveAll(rootfs)
+		if workDir == "" {
+			workDir = rootfsWorkDir
+		}
+		baseDir = rootfs
+		mounts = append(append(rootfsMounts, Mount{Source: cfg.BaseDir, Target: workDir}), cfg.Mounts...)
+	}
+
+	containerID := cfg.ID
+	if containerID == "" {
+		containerID = uuid.NewV4().String()
+	}
+	cg, err := InitCGroup(containerID, cfg.Limits)
+	if err != nil {
+		return systemError(err)
+	}
+	defer func() {
+		_ = cg.Destroy()
+	}()
+
+	resultReader, resultWriter, err := os.Pipe()
+	if err != nil {
+		return systemError(err)
+	}
+	defer func() {
+		_ = resultReader.Close()
+		_ = resultWriter.Close()
+	}()
+	startReader, startWriter, err := os.Pipe()
+	if err != nil {
+		return systemError(err)
+	}
+	defer func() {
+		_ = startReader.Close()
+		_ = startWriter.Close()
+	}()
+
+	// the config is handed over as json through the start pipe rather than as os.Args, arguments and
+	// environment of the command may hold anything
+	initCfg := *cfg
+	initCfg.BaseDir, initCfg.Mounts, initCfg.WorkDir, initCfg.Rootfs = baseDir, mounts, workDir, nil
+	spec, err := json.Marshal(&initCfg)
+	if err != nil {
+		return systemError(err)
+	}
+
+	cmd := reexec.Command("justiceInit")
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.ExtraFiles = []*os.File{resultWriter, startReader}
+	cmd.SysProcAttr = containerSysProcAttr(cfg.UID, cfg.GID)
+
+	// justiceInit enforces the timeout of the command itself, beyond the cpu time every task of the cgroup but
+	// justiceInit is killed, which then reports the command killed along with its rusage
+	supervisor := NewSupervisor(cmd, func() {
+		_ = cg.KillExcept(cmd.Process.Pid)
+	}, cg)
+	startTime := time.Now()
+	if err := supervisor.Start(); err != nil {
+		return systemError(err)
+	}
+	_ = resultWriter.Close()
+	_ = startReader.Close()
+
+	// only justiceInit and its children are in the cgroup, so the caller itself is never OOM killed
+	if err := cg.AddProcess(cmd.Process.Pid); err != nil {
+		// not in the cgroup, so it cannot be killed with it
+		_ = cmd.Process.Kill()
+		supervisor.Wait(context.Background())
+		return systemError(err)
+	}
+	// justiceInit reads until EOF, a failed write shows up as its SE result
+	_, _ = startWriter.Write(spec)
+	_ = startWriter.Close()
+	if cfg.Started != nil {
+		cfg.Started(cg)
+	}
+
+	stopWatch := watchCPUTime(cg, cfg.CPUTime, func() {
+		supervisor.Kill(KillCPUTime)
+	})
+	record, _ := ioutil.ReadAll(resultReader)
+	exit := supervisor.Wait(context.Background())
+	stopWatch()
+	wallTime := time.Since(startTime)
+
+	result, decodeErr := UnmarshalResult(record)
+	if decodeErr != nil {
+		// justiceInit died before reporting anything, e.g. killed with the cgroup
+		result = &Result{Verdict: VerdictSystemError, Error: decodeErr.Error(), WallTime: int64(wallTime / time.Millisecond)}
+		if exit.Err != nil {
+			result.Error = exit.Err.Error()
+		}
+	}
+	accountCGroup(result, cfg, cg, exit.OOMKilled, exit.Reason == KillCPUTime, decodeErr != nil)
+	return result
+}
+
+// watchCPUTime calls kill once the tasks of cg used more than cpuTime milliseconds of cpu time, 0 for no limit.
+// The cpu time of all processes and threads is only known to the cgroup. stop returns once the watch is over.
+func watchCPUTime(cg *CGroup, cpuTime int64, kill func()) (stop func()) {
+	cpuLimit := time.Duration(cpuTime) * time.Millisecond
+	if cpuLimit <= 0 {
+		return func() {}
+	}
+	done, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(cpuTimePollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if stats, err := cg.Stats(); err == nil && stats.CPUUsage > cpuLimit {
+					kill()
+					return
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// accountCGroup completes result of cfg by what cg accounted for all processes and threads, died tells that the
+// init of the sandbox reported nothing.
+func accountCGroup(result *Result, cfg *Config, cg *CGroup, oomKilled, cpuKilled, died bool) {
+	cpuLimit := time.Duration(cfg.CPUTime) * time.Millisecond
+	result.MemoryLimit = cfg.Limits.Memory
+	stats, err := cg.Stats()
+	if err != nil {
+		return
+	}
+	result.CPUTime = int64(stats.CPUUsage / time.Millisecond)
+	if stats.MemoryPeak > 0 {
+		result.Memory = stats.MemoryPeak / 1024
+	}
+	switch {
+	case oomKilled:
+		result.Verdict, result.OOMKilled, result.Killed = VerdictMemoryLimitExceeded, true, KillOOM
+	case result.Verdict == VerdictRuntimeError && result.Memory >= cfg.Limits.Memory:
+		// e.g. malloc() failed at the limit and the program crashed without being OOM killed
+		result.Verdict = VerdictMemoryLimitExceeded
+	case cpuKilled || (cpuLimit > 0 && stats.CPUUsage > cpuLimit):
+		result.Verdict, result.Error = VerdictTimeLimitExceeded, ""
+		if cpuKilled {
+			result.Killed = KillCPUTime
+		}
+		if died {
+			result.ExitCode, result.Signal = -1, int(syscall.SIGKILL)
+		}
+	}
+}
+
+// containerSysProcAttr starts the init of a container in new namespaces, the host user becomes root and uid, gid
+// become 1 of the user namespace.
+func containerSysProcAttr(uid, gid int) *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{
+		Cloneflags: syscall.CLONE_NEWNS |
+			syscall.CLONE_NEWUTS |
+			syscall.CLONE_NEWIPC |
+			syscall.CLONE_NEWPID |
+			syscall.CLONE_NEWNET |
+			syscall.CLONE_NEWUSER,
+		UidMappings: []syscall.SysProcIDMap{
+			{
+				ContainerID: 0,
+				HostID:      os.Getuid(),
+				Size:        1,
+			},
+			{
+				ContainerID: 1,
+				HostID:      uid,
+				Size:        1,
+			},
+		},
+		GidMappings: []syscall.SysProcIDMap{
+			{
+				ContainerID: 0,
+				HostID:      os.Getgid(),
+				Size:        1,
+			},
+			{
+				ContainerID: 1,
+				HostID:      gid,
+				Size:        1,
+			},
+		},
+		GidMappingsEnableSetgroups: true,
+	}
+}
+
+// justiceInit is the init of the new pid namespace, it starts justiceExec and reports the Result to Run().
+func justiceInit() {
+	// the result pipe must not be inherited by the command
+	syscall.CloseOnExec(resultFd)
+	resultPipe := os.NewFile(resultFd, "result")
+
+	// wait until Run() has put this process into the cgroup and written the config, every child is in it then
+	syscall.CloseOnExec(startFd)
+	startPipe := os.NewFile(startFd, "start")
+	spec, _ := ioutil.ReadAll(startPipe)
+	_ = startPipe.Close()
+	cfg := &Config{}
+	if err := json.Unmarshal(spec, cfg); err != nil {
+		_, _ = resultPipe.Write(MarshalResult(&Result{Verdict: VerdictSystemError, Error: err.Error()}))
+		return
+	}
+	if cfg.Quiet {
+		SetLogOutput(ioutil.Discard)
+	}
+	_, _ = resultPipe.Write(MarshalResult(runExec(cfg, os.Stdin, os.Stdout, os.Stderr, 0)))
+}
+
+// runExec starts justiceExec for cfg with the given stdio and waits for the command, in the init of the pid namespace.
+// cloneflags are the namespaces justiceExec gets of its own, e.g. a mount namespace per run of a pooled Sandbox.
+func runExec(cfg *Config, stdin, stdout, stderr *os.File, cloneflags uintptr) *Result {
+	systemError := func(err string) *Result {
+		return &Result{Verdict: VerdictSystemError, Error: err}
+	}
+
+	spec, err := json.Marshal(cfg)
+	if err != nil {
+		return systemError(err.Error())
+	}
+	execErrReader, execErrWriter, err := os.Pipe()
+	if err != nil {
+		return systemError(err.Error())
+	}
+	defer execErrReader.Close()
+	execConfigReader, execConfigWriter, err := os.Pipe()
+	if err != nil {
+		_ = execErrWriter.Close()
+		return systemError(err.Error())
+	}
+
+	// the caller is the init of the pid namespace, kill(-1) kills every other process of the sandbox,
+	// even those which left the process group and may still hold the output pipes
+	killAll := func() {
+		_ = syscall.Kill(-1, syscall.SIGKILL)
+	}
+
+	cmd := reexec.Command("justiceExec")
+	supervisor := NewSupervisor(cmd, killAll, nil)
+	outputExceeded := func() {
+		supervisor.Kill(KillOutputLimit)
+	}
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	if cfg.StdoutLimit > 0 {
+		cmd.Stdout = NewOutputLimiter(stdout, cfg.StdoutLimit*1024, outputExceeded)
+	}
+	cmd.Stderr = stderr
+	if cfg.StderrLimit > 0 {
+		cmd.Stderr = NewOutputLimiter(stderr, cfg.StderrLimit*1024, outputExceeded)
+	}
+	cmd.ExtraFiles = []*os.File{execErrWriter, execConfigReader}
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags: cloneflags,
+		Setpgid:    true,
+	}
+	// the environment of the command is only applied by execve(), it must not affect justiceExec, e.g. LD_PRELOAD
+	cmd.Env = []string{commandPS1}
+
+	err = supervisor.Start()
+	_ = execErrWriter.Close()
+	_ = execConfigReader.Close()
+	if err != nil {
+		_ = execConfigWriter.Close()
+		return systemError(err.Error())
+	}
+	_, _ = execConfigWriter.Write(spec)
+	_ = execConfigWriter.Close()
+
+	// blocks until justiceExec either reports a failure or execs the command, which closes the pipe
+	if execErr, _ := ioutil.ReadAll(execErrReader); len(execErr) > 0 {
+		supervisor.Wait(context.Background())
+		return systemError(string(execErr))
+	}
+
+	// the deadline starts once the command is exec'd
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Timeout)*time.Millisecond)
+	defer cancel()
+	exit := supervisor.Wait(ctx)
+
+	result := &Result{WallTime: int64(exit.WallTime / time.Millisecond), ExitCode: exit.ExitCode, Signal: int(exit.Signal)}
+	result.Killed = exit.Reason
+	if result.Killed == KillNone && exit.Signal != 0 {
+		result.Killed = KillSignal
+	}
+	result.CPUTime = int64((exit.State.UserTime() + exit.State.SystemTime()) / time.Millisecond)
+	result.Memory = exit.State.SysUsage().(*syscall.Rusage).Maxrss
+
+	switch {
+	case exit.Reason == KillTimeout:
+		result.Verdict = VerdictTimeLimitExceeded
+	case exit.Reason == KillOutputLimit:
+		result.Verdict = VerdictOutputLimitExceeded
+	case exit.Signal == syscall.SIGXFSZ:
+		// a file grew beyond RLIMIT_FSIZE
+		result.Verdict, result.Error = VerdictOutputLimitExceeded, exit.Err.Error()
+	case exit.Signal == syscall.SIGSYS:
+		// killed by the seccomp filter
+		result.Verdict, result.Error = VerdictRestrictedFunction, exit.Err.Error()
+	case exit.Err != nil:
+		result.Verdict, result.Error = VerdictRuntimeError, exit.Err.Error()
+	default:
+		result.Verdict = VerdictOK
+	}
+	return result
+}
+
+// justiceExec is started by justiceInit as root of the user namespace and becomes the command:
+// it changes the root filesystem, then drops to uid 1 and execs the command confined by the seccomp profile.
+// Failures before execve() are reported to justiceInit through execErrFd.
+func justiceExec() {
+	syscall.CloseOnExec(execErrFd)
+	execErrPipe := os.NewFile(execErrFd, "exec error")
+
+	fail := func(err error) {
+		_, _ = execErrPipe.WriteString(err.Error())
+		os.Exit(1)
+	}
+
+	syscall.CloseOnExec(execConfigFd)
+	execConfigPipe := os.NewFile(execConfigFd, "exec config")
+	spec, _ := ioutil.ReadAll(execConfigPipe)
+	_ = execConfigPipe.Close()
+	cfg := &Config{}
+	if err := json.Unmarshal(spec, cfg); err != nil {
+		fail(err)
+	}
+	if cfg.Quiet {
+		SetLogOutput(ioutil.Discard)
+	}
+
+	// the profile may be a file of the host, load it before pivot_root
+	profile, err := LoadSeccompProfile(cfg.Seccomp)
+	if err != nil {
+		fail(err)
+	}
+
+	if err := InitNamespace(cfg.BaseDir, cfg.Mounts); err != nil {
+		fail(err)
+	}
+	if cfg.WorkDir != "" {
+		if err := os.Chdir(cfg.WorkDir); err != nil {
+			fail(err)
+		}
+	}
+
+	// Run() enforces the cpu time of the whole cgroup, RLIMIT_CPU is the backstop of the kernel per process
+	if cfg.CPUTime > 0 {
+		seconds := uint64(cfg.CPUTime+999)/1000 + 1
+		if err := syscall.Setrlimit(syscall.RLIMIT_CPU, &syscall.Rlimit{Cur: seconds, Max: seconds + 1}); err != nil {
+			fail(err)
+		}
+	}
+
+	// writing beyond RLIMIT_FSIZE raises SIGXFSZ
+	if cfg.FileSize > 0 {
+		bytes := uint64(cfg.FileSize) * 1024
+		if err := syscall.Setrlimit(syscall.RLIMIT_FSIZE, &syscall.Rlimit{Cur: bytes, Max: bytes}); err != nil {
+			fail(err)
+		}
+	}
+
+	env := append([]string{commandPS1}, cfg.Env...)
+	path, err := lookPath(cfg.Command, env)
+	if err != nil {
+		fail(err)
+	}
+
+	fail(Exec(path, append([]string{cfg.Command}, cfg.Args...), env, 1, 1, profile))
+}
+
+// lookPath searches command in the PATH of env like a shell would, a command containing a slash is used as it is.
+func lookPath(command string, env []string) (string, error) {
+	if strings.Contains(command, "/") {
+		return exec.LookPath(command)
+	}
+	for i := len(env) - 1; i >= 0; i-- {
+		if strings.HasPrefix(env[i], "PATH=") {
+			for _, dir := range filepath.SplitList(strings.TrimPrefix(env[i], "PATH=")) {
+				if path, err := exec.LookPath(filepath.Join(dir, command)); err == nil {
+					return path, nil
+				}
+			}
+			break
+		}
+	}
+	return "", &exec.Error{Name: command, Err: exec.ErrNotFound}
+}
@@ -0,0 +1,174 @@
+// +build linux
+// +build go1.12
+
+package sandbox
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// severities of a Diagnostic
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityNote    = "note"
+)
+
+// Diagnostic is one message of the compiler, Line and Column start at 1 and are 0 if unknown.
+type Diagnostic struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+var (
+	// gcc, clang and javac: Main.c:3:5: error: message, go: ./Main.go:3:5: message
+	classicDiagnostic = regexp.MustCompile(`^(.+?):(\d+):(?:(\d+):)? (?:(fatal error|error|warning|note|remark)(?: \[\w+])?: )?(.*)$`)
+	// fpc: Main.pas(3,5) Error: message
+	pascalDiagnostic = regexp.MustCompile(`^(.+?)\((\d+),(\d+)\) (Fatal|Error|Warning|Note|Hint): (.*)$`)
+	// rustc: error[E0425]: message, followed by --> Main.rs:3:5
+	rustDiagnostic = regexp.MustCompile(`^(error|warning|note)(?:\[\w+])?: (.*)$`)
+	rustLocation   = regexp.MustCompile(`^\s*--> (.+?):(\d+):(\d+)$`)
+	// ld: Main.c:(.text+0x9): undefined reference to `f'
+	linkerDiagnostic = regexp.MustCompile(`^(.+?):\(\.[\w.]+\+0x[0-9a-f]+\): (.*)$`)
+	// collect2: error: ld returned 1 exit status, cc1: fatal error: Main.c: No such file or directory
+	toolDiagnostic = regexp.MustCompile(`^([\w./-]+): (fatal error|error|warning): (.*)$`)
+)
+
+// gccDiagnostic is an element of the output of gcc -fdiagnostics-format=json.
+type gccDiagnostic struct {
+	Kind      string `json:"kind"`
+	Message   string `json:"message"`
+	Locations []struct {
+		Caret struct {
+			File   string `json:"file"`
+			Line   int    `json:"line"`
+			Column int    `json:"column"`
+		} `json:"caret"`
+	} `json:"locations"`
+	Children []*gccDiagnostic `json:"children"`
+}
+
+// ParseDiagnostics extracts the diagnostics from the output of a compiler, either in the classic text format or a
+// JSON array written by -fdiagnostics-format=json. Lines of neither, e.g. the source excerpts, are skipped.
+//noinspection GoUnusedExportedFunction
+func ParseDiagnostics(output []byte) []Diagnostic {
+	diagnostics := make([]Diagnostic, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), len(output)+1)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.HasPrefix(line, "[") {
+			var gcc []*gccDiagnostic
+			if err := json.Unmarshal([]byte(line), &gcc); err == nil {
+				diagnostics = appendGCCDiagnostics(diagnostics, gcc)
+				continue
+			}
+		}
+		if m := rustLocation.FindStringSubmatch(line); m != nil {
+			// the location of the preceding rustc diagnostic
+			if n := len(diagnostics); n > 0 && diagnostics[n-1].File == "" {
+				diagnostics[n-1].File = m[1]
+				diagnostics[n-1].Line, _ = strconv.Atoi(m[2])
+				diagnostics[n-1].Column, _ = strconv.Atoi(m[3])
+			}
+			continue
+		}
+		if d, ok := parseDiagnostic(line); ok {
+			diagnostics = append(diagnostics, d)
+		}
+	}
+	return diagnostics
+}
+
+// readableOutput returns output with the JSON arrays of -fdiagnostics-format=json replaced by their diagnostics in the
+// classic text format, see formatDiagnostics.
+func readableOutput(output []byte) string {
+	var readable strings.Builder
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), len(output)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "[") {
+			var gcc []*gccDiagnostic
+			if err := json.Unmarshal([]byte(line), &gcc); err == nil {
+				readable.WriteString(formatDiagnostics(appendGCCDiagnostics(nil, gcc)))
+				continue
+			}
+		}
+		readable.WriteString(line + "\n")
+	}
+	return readable.String()
+}
+
+// formatDiagnostics renders diagnostics a line each in the classic text format, file:line:column: severity: message.
+func formatDiagnostics(diagnostics []Diagnostic) string {
+	var output strings.Builder
+	for _, d := range diagnostics {
+		if d.Line == 0 {
+			output.WriteString(fmt.Sprintf("%s: %s: %s\n", d.File, d.Severity, d.Message))
+			continue
+		}
+		output.WriteString(fmt.Sprintf("%s:%d:%d: %s: %s\n", d.File, d.Line, d.Column, d.Severity, d.Message))
+	}
+	return output.String()
+}
+
+// parseDiagnostic parses a line of the text format of one of the compilers.
+func parseDiagnostic(line string) (Diagnostic, bool) {
+	if m := classicDiagnostic.FindStringSubmatch(line); m != nil {
+		d := Diagnostic{File: m[1], Severity: severity(m[4]), Message: m[5]}
+		d.Line, _ = strconv.Atoi(m[2])
+		d.Column, _ = strconv.Atoi(m[3])
+		return d, true
+	}
+	if m := pascalDiagnostic.FindStringSubmatch(line); m != nil {
+		d := Diagnostic{File: m[1], Severity: severity(m[4]), Message: m[5]}
+		d.Line, _ = strconv.Atoi(m[2])
+		d.Column, _ = strconv.Atoi(m[3])
+		return d, true
+	}
+	if m := rustDiagnostic.FindStringSubmatch(line); m != nil {
+		return Diagnostic{Severity: severity(m[1]), Message: m[2]}, true
+	}
+	if m := linkerDiagnostic.FindStringSubmatch(line); m != nil {
+		return Diagnostic{File: m[1], Severity: SeverityError, Message: m[2]}, true
+	}
+	if m := toolDiagnostic.FindStringSubmatch(line); m != nil {
+		return Diagnostic{File: m[1], Severity: severity(m[2]), Message: m[3]}, true
+	}
+	return Diagnostic{}, false
+}
+
+// appendGCCDiagnostics flattens gcc into diagnostics, the children follow their parent.
+func appendGCCDiagnostics(diagnostics []Diagnostic, gcc []*gccDiagnostic) []Diagnostic {
+	for _, g := range gcc {
+		d := Diagnostic{Severity: severity(g.Kind), Message: g.Message}
+		if len(g.Locations) > 0 {
+			caret := g.Locations[0].Caret
+			d.File, d.Line, d.Column = caret.File, caret.Line, caret.Column
+		}
+		diagnostics = appendGCCDiagnostics(append(diagnostics, d), g.Children)
+	}
+	return diagnostics
+}
+
+// severity maps the severities of the compilers to SeverityError, SeverityWarning or SeverityNote,
+// messages without any, e.g. of go, are errors.
+func severity(s string) string {
+	switch strings.ToLower(s) {
+	case "warning":
+		return SeverityWarning
+	case "note", "remark", "hint":
+		return SeverityNote
+	}
+	return SeverityError
+}
@@ -0,0 +1,71 @@
+// +build linux
+// +build go1.12
+
+package sandbox
+
+import (
+	"fmt"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// Exec replaces the current process by argv0 running as uid and gid, confined by the seccomp profile if not nil.
+// It only returns on failure.
+//
+// The credentials and the seccomp filter are applied to the calling thread only, execve() then hands them over
+// to the new program. The other threads of the Go runtime are left alone and die with execve().
+//noinspection GoUnusedExportedFunction
+func Exec(argv0 string, argv []string, envv []string, uid, gid int, profile *SeccompProfile) error {
+	var filter []syscall.SockFilter
+	if profile != nil {
+		var err error
+		if filter, err = profile.filter(); err != nil {
+			_, _ = logger.WriteString(fmt.Sprintf("profile.filter() failed, err: %s\n", err.Error()))
+			return err
+		}
+	}
+
+	// everything execve() needs is allocated before the filter is installed
+	argv0p, err := syscall.BytePtrFromString(argv0)
+	if err != nil {
+		return err
+	}
+	argvp, err := syscall.SlicePtrFromStrings(argv)
+	if err != nil {
+		return err
+	}
+	envvp, err := syscall.SlicePtrFromStrings(envv)
+	if err != nil {
+		return err
+	}
+
+	// never unlocked, the thread may have lost its privileges if execve() fails
+	runtime.LockOSThread()
+
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_SETGROUPS, 0, 0, 0); errno != 0 {
+		_, _ = logger.WriteString(fmt.Sprintf("setgroups(0, NULL) failed, err: %s\n", errno.Error()))
+		return errno
+	}
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_SETGID, uintptr(gid), 0, 0); errno != 0 {
+		_, _ = logger.WriteString(fmt.Sprintf("setgid(%d) failed, err: %s\n", gid, errno.Error()))
+		return errno
+	}
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_SETUID, uintptr(uid), 0, 0); errno != 0 {
+		_, _ = logger.WriteString(fmt.Sprintf("setuid(%d) failed, err: %s\n", uid, errno.Error()))
+		return errno
+	}
+
+	if filter != nil {
+		if errno := installSeccomp(filter); errno != 0 {
+			_, _ = logger.WriteString(fmt.Sprintf("installSeccomp(%s) failed, err: %s\n", profile.Name, errno.Error()))
+			return errno
+		}
+	}
+
+	_, _, errno := syscall.RawSyscall(syscall.SYS_EXECVE,
+		uintptr(unsafe.Pointer(argv0p)),
+		uintptr(unsafe.Pointer(&argvp[0])),
+		uintptr(unsafe.Pointer(&envvp[0])))
+	return errno
+}
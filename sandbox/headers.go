@@ -0,0 +1,255 @@
+// +build linux
+// +build go1.12
+
+package sandbox
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// directory in the root filesystem of the compiler shadowing the forbidden headers, searched first
+const forbiddenHeadersDir = "/forbidden"
+
+// the digraph %: is # as well
+var includeDirective = regexp.MustCompile(`^\s*(?:#|%:)\s*(include|include_next|import)\b\s*(.*)$`)
+
+// replaced first by the compilers in strict ISO modes, e.g. ??=include under -std=c11, and by checkIncludes in any
+var trigraphs = strings.NewReplacer(
+	"??=", "#", "??/", "\\", "??'", "^", "??(", "[", "??)", "]", "??!", "|", "??<", "{", "??>", "}", "??-", "~",
+)
+
+// includeDir returns where the i-th of CompileConfig.IncludeDirs is mounted.
+func includeDir(i int) string {
+	return "/include/" + strconv.Itoa(i)
+}
+
+// writeForbiddenHeaders shadows every header of headers by one failing with #error.
+func writeForbiddenHeaders(rootfs string, headers []string) error {
+	for _, header := range headers {
+		name := filepath.Join(rootfs, forbiddenHeadersDir, filepath.Clean(header))
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			return err
+		}
+		content := fmt.Sprintf("#error \"header <%s> is forbidden\"\n", header)
+		if err := ioutil.WriteFile(name, []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkIncludes reports the includes of the sources in baseDir which escape the include path and thus the headers
+// shadowing the forbidden ones: those by an abs path or a path with .., and computed includes. The includes by another
+// relative path, e.g. <x86_64-linux-gnu/sys/time.h> for <sys/time.h>, are left to checkDependencies.
+func checkIncludes(baseDir string, sources []string) ([]Diagnostic, error) {
+	var diagnostics []Diagnostic
+	for _, name := range sources {
+		f, err := openRegular(filepath.Join(baseDir, name))
+		if err != nil {
+			return nil, err
+		}
+		source, err := ioutil.ReadAll(f)
+		_ = f.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range logicalLines([]byte(trigraphs.Replace(string(source)))) {
+			m := includeDirective.FindStringSubmatch(line.text)
+			if m == nil {
+				continue
+			}
+			d := Diagnostic{File: name, Line: line.number, Column: 1, Severity: SeverityError}
+			operand := strings.TrimSpace(m[2])
+			end := -1
+			if len(operand) > 0 && operand[0] == '<' {
+				end = strings.IndexByte(operand[1:], '>')
+			} else if len(operand) > 0 && operand[0] == '"' {
+				end = strings.IndexByte(operand[1:], '"')
+			}
+			if end < 0 {
+				d.Message = fmt.Sprintf("computed #%s is forbidden", m[1])
+				diagnostics = append(diagnostics, d)
+				continue
+			}
+			path := operand[1 : end+1]
+			if filepath.IsAbs(path) || containsDotDot(path) {
+				d.Message = fmt.Sprintf("#%s of %s by path is forbidden", m[1], operand[:end+2])
+				diagnostics = append(diagnostics, d)
+			}
+		}
+	}
+	return diagnostics, nil
+}
+
+// checkDependencies reports the headers in rules, the make rules of the sources written by
+// LanguageProfile.Dependencies, which resolve to one of headers, i.e. every path of the root filesystem of the compiler
+// ending with a forbidden header once its symlinks are resolved. includeDirs are the host directories of
+// CompileConfig.IncludeDirs, the files in the scratch directory and the shadows of writeForbiddenHeaders are skipped.
+func checkDependencies(rules []byte, includeDirs, headers []string) []Diagnostic {
+	var diagnostics []Diagnostic
+	reported := make(map[string]bool)
+	for _, line := range strings.Split(strings.Replace(string(rules), "\\\n", " ", -1), "\n") {
+		i := strings.Index(line, ": ")
+		if i < 0 {
+			continue
+		}
+		// the first prerequisite of a rule is the source
+		dependencies := makeWords(line[i+2:])
+		for j := 1; j < len(dependencies); j++ {
+			path := dependencies[j]
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(rootfsWorkDir, path)
+			}
+			path = filepath.Clean(path)
+			if isBelow(rootfsWorkDir, path) || isBelow(forbiddenHeadersDir, path) {
+				continue
+			}
+			hostPath := path
+			for k, dir := range includeDirs {
+				if isBelow(includeDir(k), path) {
+					rel, _ := filepath.Rel(includeDir(k), path)
+					hostPath = filepath.Join(dir, rel)
+				}
+			}
+			// the toolchain is mounted at the same path as on the host
+			resolved, err := filepath.EvalSymlinks(hostPath)
+			if err != nil {
+				resolved = hostPath
+			}
+			for _, header := range headers {
+				suffix := "/" + filepath.Clean(header)
+				key := dependencies[0] + "\x00" + header
+				if reported[key] || (!strings.HasSuffix(path, suffix) && !strings.HasSuffix(resolved, suffix)) {
+					continue
+				}
+				reported[key] = true
+				diagnostics = append(diagnostics, Diagnostic{
+					File:     dependencies[0],
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("header <%s> is forbidden, it is included as %s", header, dependencies[j]),
+				})
+			}
+		}
+	}
+	return diagnostics
+}
+
+// makeWords splits the prerequisites of a make rule written by a compiler, which escapes spaces, # and $ of paths.
+func makeWords(s string) []string {
+	var words []string
+	var word strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && (s[i+1] == ' ' || s[i+1] == '#'):
+			word.WriteByte(s[i+1])
+			i++
+		case c == '$' && i+1 < len(s) && s[i+1] == '$':
+			word.WriteByte('$')
+			i++
+		case c == ' ' || c == '\t':
+			if word.Len() > 0 {
+				words = append(words, word.String())
+				word.Reset()
+			}
+		default:
+			word.WriteByte(c)
+		}
+	}
+	if word.Len() > 0 {
+		words = append(words, word.String())
+	}
+	return words
+}
+
+func containsDotDot(path string) bool {
+	for _, element := range strings.Split(filepath.ToSlash(path), "/") {
+		if element == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+type logicalLine struct {
+	text string
+	// of the first physical line
+	number int
+}
+
+// logicalLines splits C source into the lines seen by the preprocessor: backslash-newlines are spliced and comments
+// replaced by a space, so that neither hides a directive.
+func logicalLines(source []byte) []logicalLine {
+	const (
+		code = iota
+		stringLiteral
+		charLiteral
+		lineComment
+		blockComment
+	)
+	var lines []logicalLine
+	var text bytes.Buffer
+	state, number, start := code, 1, 1
+	for i := 0; i < len(source); i++ {
+		c := source[i]
+		next := byte(0)
+		if i+1 < len(source) {
+			next = source[i+1]
+		}
+		if c == '\\' && next == '\n' {
+			i++
+			number++
+			continue
+		}
+		if c == '\n' {
+			number++
+			if state == blockComment {
+				continue
+			}
+			lines = append(lines, logicalLine{text: text.String(), number: start})
+			text.Reset()
+			state, start = code, number
+			continue
+		}
+
+		switch state {
+		case code:
+			switch {
+			case c == '/' && next == '*':
+				state = blockComment
+				text.WriteByte(' ')
+				i++
+			case c == '/' && next == '/':
+				state = lineComment
+			default:
+				if c == '"' {
+					state = stringLiteral
+				} else if c == '\'' {
+					state = charLiteral
+				}
+				text.WriteByte(c)
+			}
+		case stringLiteral, charLiteral:
+			text.WriteByte(c)
+			if c == '\\' && next != '\n' && next != 0 {
+				text.WriteByte(next)
+				i++
+			} else if (state == stringLiteral && c == '"') || (state == charLiteral && c == '\'') {
+				state = code
+			}
+		case blockComment:
+			if c == '*' && next == '/' {
+				state = code
+				i++
+			}
+		}
+	}
+	return append(lines, logicalLine{text: text.String(), number: start})
+}
@@ -0,0 +1,135 @@
+// +build linux
+// +build go1.12
+
+package sandbox
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+)
+
+// HostDir confines the host paths chosen by the clients of a daemon running as root to a directory, e.g. the
+// directories of the submissions and their input and output files.
+type HostDir string
+
+// NewHostDir returns the HostDir of the existing directory dir, with its symlinks resolved.
+//noinspection GoUnusedExportedFunction
+func NewHostDir(dir string) (HostDir, error) {
+	if !filepath.IsAbs(dir) {
+		return "", fmt.Errorf("invalid directory %q, must be an abs path", dir)
+	}
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", dir)
+	}
+	return HostDir(resolved), nil
+}
+
+// Resolve returns the abs path of name below d, a relative name is relative to d. Symlinks are resolved so that none
+// leads outside of d, the last element of name need not exist.
+func (d HostDir) Resolve(name string) (string, error) {
+	if d == "" {
+		return "", fmt.Errorf("no directory to resolve %q in", name)
+	}
+	path := name
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(string(d), path)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if os.IsNotExist(err) {
+		var parent string
+		if parent, err = filepath.EvalSymlinks(filepath.Dir(path)); err == nil {
+			resolved = filepath.Join(parent, filepath.Base(path))
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	if !isBelow(string(d), resolved) {
+		return "", fmt.Errorf("invalid path %q, must be below %s", name, d)
+	}
+	return resolved, nil
+}
+
+// isBelow returns whether the clean abs path is dir or below it.
+func isBelow(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, "../")
+}
+
+// Open opens name below d for reading, see Resolve.
+func (d HostDir) Open(name string) (*os.File, error) {
+	path, err := d.Resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	// a symlink replacing the file since Resolve is not followed
+	return os.OpenFile(path, os.O_RDONLY|syscall.O_NOFOLLOW, 0)
+}
+
+// Create creates or truncates name below d, see Resolve.
+func (d HostDir) Create(name string) (*os.File, error) {
+	path, err := d.Resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	return os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC|syscall.O_NOFOLLOW, 0666)
+}
+
+// openRegular opens the regular file name for reading unless its last element is a symlink, e.g. a source of a
+// submission planted as a link to /etc/shadow. A fifo fails rather than blocks.
+func openRegular(name string) (*os.File, error) {
+	f, err := os.OpenFile(name, os.O_RDONLY|syscall.O_NOFOLLOW|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := f.Stat(); err != nil || !info.Mode().IsRegular() {
+		_ = f.Close()
+		return nil, fmt.Errorf("%s is not a regular file", name)
+	}
+	return f, nil
+}
+
+// openAs opens name for reading with the filesystem credentials of uid and gid rather than those of root, so that only
+// what the user may read is opened, e.g. not /etc/shadow.
+func openAs(name string, uid, gid int) (*os.File, error) {
+	type opened struct {
+		f   *os.File
+		err error
+	}
+	ch := make(chan opened, 1)
+	go func() {
+		// the thread is never unlocked, it exits with the goroutine rather than running others with the credentials
+		runtime.LockOSThread()
+		// the raw syscalls change the credentials of this thread only, unlike syscall.Setgroups, and a uid other than 0
+		// drops the capabilities overriding the permissions of files
+		if _, _, errno := syscall.RawSyscall(syscall.SYS_SETGROUPS, 0, 0, 0); errno != 0 {
+			ch <- opened{nil, os.NewSyscallError("setgroups", errno)}
+			return
+		}
+		_, _, _ = syscall.RawSyscall(syscall.SYS_SETFSGID, uintptr(gid), 0, 0)
+		_, _, _ = syscall.RawSyscall(syscall.SYS_SETFSUID, uintptr(uid), 0, 0)
+		// setfsuid and setfsgid do not fail but return the previous id, which is the current one after -1
+		fsgid, _, _ := syscall.RawSyscall(syscall.SYS_SETFSGID, ^uintptr(0), 0, 0)
+		fsuid, _, _ := syscall.RawSyscall(syscall.SYS_SETFSUID, ^uintptr(0), 0, 0)
+		if int(fsuid) != uid || int(fsgid) != gid {
+			ch <- opened{nil, fmt.Errorf("failed to open %s as %d:%d", name, uid, gid)}
+			return
+		}
+		f, err := os.Open(name)
+		ch <- opened{f, err}
+	}()
+	o := <-ch
+	return o.f, o.err
+}
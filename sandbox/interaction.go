@@ -0,0 +1,197 @@
+// +build linux
+// +build go1.12
+
+package sandbox
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// the interactor runs as `./interactor input.txt output.txt answer.txt` in its own basedir
+const interactorCommand = "./interactor"
+
+// Interaction runs the command together with an interactor of the problem setter, each in a sandbox of its own,
+// the stdout of either is the stdin of the other. The interactor reads the test from input.txt, testlib style,
+// and decides the verdict by its exit code.
+type Interaction struct {
+	// path of the interactor on the host, it must be a static binary as it runs alone in a fresh basedir
+	Interactor string
+	// limits of the interactor, BaseDir, Command, Args and Started are overwritten
+	Config *Config
+	// both sides are killed once they used next to no cpu time for IdleLimit, 0 for no limit
+	IdleLimit time.Duration
+}
+
+// Run runs cfg against the interactor, with input and expected as input.txt and answer.txt of the interactor.
+// Started of cfg is overwritten.
+// The Result is of the command with VerdictAccepted, VerdictWrongAnswer or VerdictPresentationError
+// if it ran fine, the interactor's own Result is attached.
+func (i *Interaction) Run(cfg *Config, input, expected io.Reader, stderr io.Writer) *Result {
+	systemError := func(err error) *Result {
+		return &Result{Verdict: VerdictSystemError, Error: err.Error()}
+	}
+
+	dir, err := newJudgeDir(i.Interactor, interactorCommand,
+		judgeFile{checkerInput, input, 0644},
+		judgeFile{checkerOutput, strings.NewReader(""), 0666},
+		judgeFile{checkerAnswer, expected, 0644},
+	)
+	if err != nil {
+		return systemError(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	// command => interactor and interactor => command
+	toInteractor, fromCommand, err := os.Pipe()
+	if err != nil {
+		return systemError(err)
+	}
+	toCommand, fromInteractor, err := os.Pipe()
+	if err != nil {
+		_ = toInteractor.Close()
+		_ = fromCommand.Close()
+		return systemError(err)
+	}
+
+	var idle int32
+	watcher := newIdleWatcher(i.IdleLimit, func() {
+		atomic.StoreInt32(&idle, 1)
+	})
+
+	// either side sees EOF only once the pipes of this process are closed too,
+	// so they are closed as soon as the side is started, or at the latest when it is done
+	var wg sync.WaitGroup
+	run := func(cfg *Config, stdin *os.File, stdout *os.File, stderr io.Writer, result **Result) {
+		defer wg.Done()
+		var once sync.Once
+		closePipes := func() {
+			once.Do(func() {
+				_ = stdin.Close()
+				_ = stdout.Close()
+			})
+		}
+		defer closePipes()
+
+		cfg.Started = func(cg *CGroup) {
+			closePipes()
+			watcher.add(cg)
+		}
+		*result = Run(cfg, stdin, stdout, stderr)
+		watcher.done()
+	}
+
+	commandCfg := *cfg
+	interactorCfg := *i.Config
+	interactorCfg.BaseDir, interactorCfg.Command = dir, interactorCommand
+	interactorCfg.Args = []string{checkerInput, checkerOutput, checkerAnswer}
+	if cfg.ID != "" {
+		// both sides run at the same time, in cgroups of their own
+		interactorCfg.ID = cfg.ID + "-interactor"
+	}
+	var message bytes.Buffer
+
+	var result, interacted *Result
+	wg.Add(2)
+	go run(&commandCfg, toCommand, fromCommand, stderr, &result)
+	go run(&interactorCfg, toInteractor, fromInteractor, NewOutputLimiter(&message, checkerMessageLimit, func() {}), &interacted)
+	wg.Wait()
+
+	result.Interactor, result.Message = interacted, strings.TrimSpace(message.String())
+	if atomic.LoadInt32(&idle) == 1 {
+		result.Verdict, result.Error = VerdictIdleLimitExceeded, ""
+		return result
+	}
+	if result.Verdict == VerdictSystemError {
+		return result
+	}
+
+	verdict, err := testlibVerdict("interactor", interacted)
+	switch {
+	case err != nil:
+		result.Verdict, result.Error = VerdictSystemError, err.Error()
+	case verdict != VerdictAccepted && (result.Verdict == VerdictOK || result.Signal == int(syscall.SIGPIPE)):
+		// the command was killed by SIGPIPE writing to an interactor which already gave up on it
+		result.Verdict, result.Error = verdict, ""
+	case result.Verdict == VerdictOK:
+		result.Verdict = verdict
+	}
+	return result
+}
+
+// idleWatcher polls the cpu time of the cgroups of an Interaction and kills all of them,
+// if they used next to no cpu time for limit, i.e. all sides are blocked on each other.
+// Some cpu time is always used, as the runtime of justiceInit wakes up now and then.
+type idleWatcher struct {
+	limit time.Duration
+	idle  func()
+	mutex sync.Mutex
+	cgs   []*CGroup
+	// closed by the first side which is done, the remaining sides can not block on it anymore
+	finish chan struct{}
+	once   sync.Once
+}
+
+func newIdleWatcher(limit time.Duration, idle func()) *idleWatcher {
+	return &idleWatcher{limit: limit, idle: idle, finish: make(chan struct{})}
+}
+
+// add watches cg, watching starts once both sides are added.
+func (w *idleWatcher) add(cg *CGroup) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.cgs = append(w.cgs, cg)
+	if w.limit > 0 && len(w.cgs) == 2 {
+		go w.watch(w.cgs)
+	}
+}
+
+func (w *idleWatcher) done() {
+	w.once.Do(func() {
+		close(w.finish)
+	})
+}
+
+func (w *idleWatcher) watch(cgs []*CGroup) {
+	ticker := time.NewTicker(cpuTimePollInterval)
+	defer ticker.Stop()
+
+	// less than 1% of a cpu is idle
+	threshold := w.limit / 100
+	var lastUsage time.Duration
+	lastProgress := time.Now()
+	for {
+		select {
+		case <-w.finish:
+			return
+		case <-ticker.C:
+			var usage time.Duration
+			for _, cg := range cgs {
+				stats, err := cg.Stats()
+				if err != nil {
+					return
+				}
+				usage += stats.CPUUsage
+			}
+			if usage-lastUsage > threshold {
+				lastUsage, lastProgress = usage, time.Now()
+				continue
+			}
+			if time.Since(lastProgress) > w.limit {
+				w.idle()
+				for _, cg := range cgs {
+					_ = cg.Kill()
+				}
+				return
+			}
+		}
+	}
+}
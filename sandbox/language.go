@@ -0,0 +1,345 @@
+// +build linux
+// +build go1.12
+
+package sandbox
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"sigs.k8s.io/yaml"
+)
+
+// placeholders in LanguageProfile.Args
+const (
+	sourcePlaceholder = "{source}"
+	// an argument of its own, replaced by Source and CompileConfig.Sources
+	sourcesPlaceholder = "{sources}"
+	outputPlaceholder  = "{output}"
+	stdPlaceholder     = "{std}"
+)
+
+// LanguageProfile describes how a compiler turns Source into Output, the fields of CompileConfig override it,
+// and how the submission is run if Output is not a static executable, e.g. by an interpreter.
+type LanguageProfile struct {
+	Name string `json:"name"`
+	// compiler with abs path, empty for interpreted languages
+	Compiler string `json:"compiler"`
+	// arguments of Compiler, {source}, {output} and {std} are replaced by Source, Output and Std,
+	// {sources} by Source and the other sources of a multi-file submission, see CompileConfig.Sources
+	Args []string `json:"args"`
+	// name of the submission, e.g. Main.java
+	Source string `json:"source"`
+	// artifact the compiler must write next to Source, e.g. Main.class
+	Output string `json:"output"`
+	// patterns of the files copied back into CompileConfig.BaseDir, e.g. *.class, only Output if empty,
+	// intermediate files like those of -save-temps are removed with the scratch directory
+	Artifacts []string `json:"artifacts"`
+	// language standard, e.g. gnu11, empty if Args do not refer to it
+	Std string `json:"std"`
+	// patterns of the flags a compilation may add to Args, see CompileConfig.Flags,
+	// a pattern ending with * allows every flag starting with the rest, e.g. -D*
+	AllowedFlags []string `json:"allowedFlags"`
+	// argument of Compiler prefixing an include directory, e.g. -I, empty if the language has none,
+	// see CompileConfig.IncludeDirs and CompileConfig.ForbiddenHeaders
+	Include string `json:"include"`
+	// arguments of Compiler printing the make rules of the headers of {sources} to stdout instead of compiling, e.g.
+	// -M, which must not resolve to a forbidden header, see CompileConfig.ForbiddenHeaders
+	Dependencies []string `json:"dependencies"`
+	// environment of Compiler in the form key=value, besides PATH and TMPDIR
+	Env []string `json:"env"`
+	// timeout in milliseconds and memory limit in KB of the compiler
+	Timeout int64 `json:"timeout"`
+	Memory  int64 `json:"memory"`
+	// max number of tasks, e.g. the threads of a JVM
+	Pids int64 `json:"pids"`
+	// host paths mounted read-only besides CompileConfig.Toolchain, missing ones are skipped
+	Toolchain []string `json:"toolchain"`
+	// nil if Output is run as it is, see Config.Command
+	Run *RunProfile `json:"run"`
+	// seccomp profile of the submission, see Config.Seccomp, empty for none, e.g. a runtime needing syscalls beyond
+	// the allowlist of c like a JVM
+	Seccomp string `json:"seccomp"`
+}
+
+// RunProfile describes the runtime of a language, e.g. an interpreter.
+type RunProfile struct {
+	// runtime with abs path, replaces Config.Command
+	Command string `json:"command"`
+	// arguments of Command before Config.Args, {source} and {output} are replaced by the files of the language
+	Args []string `json:"args"`
+	// environment of Command in the form key=value, before Config.Env
+	Env []string `json:"env"`
+	// host paths mounted read-only in the root filesystem of Command, see Config.Rootfs
+	Rootfs []string `json:"rootfs"`
+	// multipliers of the time and memory limits, 1 if 0
+	TimeFactor   float64 `json:"timeFactor"`
+	MemoryFactor float64 `json:"memoryFactor"`
+}
+
+// the diagnostics are written as json, see ParseDiagnostics, those of the driver and the linker stay text
+var gccArgs = []string{sourcesPlaceholder, "-save-temps", "-std=" + stdPlaceholder, "-fmax-errors=10", "-fdiagnostics-format=json",
+	"-static", "-o", outputPlaceholder}
+
+var gccDependencies = []string{sourcesPlaceholder, "-std=" + stdPlaceholder, "-M"}
+
+// flags of gcc and g++ allowed by default, -fsanitize=address does not link with -static
+var gccAllowedFlags = []string{
+	"-O0", "-O1", "-O2", "-O3", "-Os", "-g", "-lm", "-pthread", "-D*", "-U*",
+	"-w", "-Wall", "-Wextra", "-Werror", "-Wpedantic", "-pedantic", "-fsanitize=undefined", "-fno-sanitize-recover",
+}
+
+// deniedFlags are prefixes of the flags which run or load programs other than the compiler, e.g. a plugin or a
+// linker, read arguments from files or redirect the output, they are rejected even if allowed by a profile.
+var deniedFlags = []string{
+	"@", "-B", "-fplugin", "-wrapper", "-specs", "--specs", "-Wl,", "-Xlinker", "--for-linker", "-Wa,", "-Xassembler",
+	"--for-assembler", "-Wp,", "-Xpreprocessor", "-fuse-ld", "--sysroot", "-isysroot", "-iplugindir", "-o", "--output",
+	"-save-temps", "--save-temps", "-dump", "--dump", "-J",
+}
+
+// host paths of the runtimes of the built-in profiles, /etc/alternatives resolves e.g. /usr/bin/java
+var runtimeRootfs = []string{"/usr", "/bin", "/lib", "/lib64", "/etc/alternatives"}
+
+var builtinLanguageProfiles = []*LanguageProfile{
+	{
+		Name: "c", Compiler: "/usr/bin/gcc", Args: gccArgs, Source: "Main.c", Output: "Main", Std: "gnu11", Include: "-I",
+		Dependencies: gccDependencies, Timeout: 5000, Memory: 256 * 1024, Pids: 32,
+		AllowedFlags: gccAllowedFlags, Seccomp: "c",
+	},
+	{
+		Name: "cpp", Compiler: "/usr/bin/g++", Args: gccArgs, Source: "Main.cpp", Output: "Main", Std: "gnu++14", Include: "-I",
+		Dependencies: gccDependencies, Timeout: 5000, Memory: 256 * 1024, Pids: 32,
+		AllowedFlags: gccAllowedFlags, Seccomp: "cpp",
+	},
+	{
+		Name: "java", Compiler: "/usr/bin/javac", Args: []string{"-J-Xmx256m", "-encoding", "UTF-8", "-d", ".", sourcesPlaceholder},
+		Source: "Main.java", Output: "Main.class", Artifacts: []string{"*.class"},
+		Timeout: 10000, Memory: 512 * 1024, Pids: 128,
+		// the conf of a Debian JDK links to /etc
+		Toolchain: javaConf,
+		Run: &RunProfile{
+			Command: "/usr/bin/java", Args: []string{"-Xss64m", "-XX:+UseSerialGC", "-cp", ".", "Main"},
+			Rootfs:     append(append([]string{}, runtimeRootfs...), javaConf...),
+			TimeFactor: 2, MemoryFactor: 2,
+		},
+	},
+	{
+		Name: "go", Compiler: "/usr/local/go/bin/go", Args: []string{"build", "-o", outputPlaceholder, sourcesPlaceholder},
+		Source: "Main.go", Output: "Main",
+		Env: []string{"HOME=/code", "GOROOT=/usr/local/go", "GOCACHE=/code/.cache", "GOPATH=/code/.go",
+			"CGO_ENABLED=0", "GOTOOLCHAIN=local", "GOTELEMETRY=off"},
+		// the build cache starts empty, the packages of the standard library imported are built every time
+		Timeout: 30000, Memory: 512 * 1024, Pids: 128,
+	},
+	{
+		Name: "rust", Compiler: "/usr/bin/rustc", Args: []string{"-O", "-C", "linker=/usr/bin/gcc", "-o", outputPlaceholder, sourcePlaceholder},
+		Source: "Main.rs", Output: "Main",
+		Timeout: 10000, Memory: 512 * 1024, Pids: 64,
+	},
+	{
+		Name: "pascal", Compiler: "/usr/bin/fpc", Args: []string{"-O2", "-Xs", "-o" + outputPlaceholder, sourcePlaceholder},
+		Source: "Main.pas", Output: "Main",
+		Timeout: 5000, Memory: 256 * 1024, Pids: 32,
+		Toolchain: []string{"/etc/fpc.cfg"},
+	},
+	{
+		Name: "python", Source: "Main.py",
+		Run: &RunProfile{
+			Command: "/usr/bin/python3", Args: []string{sourcePlaceholder},
+			Env:        []string{"PYTHONIOENCODING=utf-8", "PYTHONDONTWRITEBYTECODE=1"},
+			Rootfs:     runtimeRootfs,
+			TimeFactor: 3, MemoryFactor: 2,
+		},
+	},
+	{
+		Name: "node", Source: "Main.js",
+		Run: &RunProfile{
+			Command: "/usr/bin/node", Args: []string{"--stack-size=65500", sourcePlaceholder},
+			Rootfs:     runtimeRootfs,
+			TimeFactor: 2, MemoryFactor: 2,
+		},
+	},
+	{
+		Name: "ruby", Source: "Main.rb",
+		Run: &RunProfile{
+			Command: "/usr/bin/ruby", Args: []string{sourcePlaceholder},
+			Rootfs:     runtimeRootfs,
+			TimeFactor: 3, MemoryFactor: 2,
+		},
+	},
+}
+
+var javaConf = []string{"/etc/java-11-openjdk", "/etc/java-17-openjdk", "/etc/java-21-openjdk"}
+
+var (
+	languageProfiles = make(map[string]*LanguageProfile)
+	languageMutex    sync.RWMutex
+)
+
+func init() {
+	for _, profile := range builtinLanguageProfiles {
+		languageProfiles[profile.Name] = profile
+	}
+}
+
+// LoadLanguageProfiles reads a YAML or JSON list of LanguageProfile from the file name, which add to the built-in
+// profiles and replace those of the same name.
+//noinspection GoUnusedExportedFunction
+func LoadLanguageProfiles(name string) error {
+	c, err := ioutil.ReadFile(name)
+	if err != nil {
+		_, _ = logger.WriteString(fmt.Sprintf("ioutil.ReadFile(%s) failed, err: %s\n", name, err.Error()))
+		return err
+	}
+	var profiles []*LanguageProfile
+	if err := yaml.Unmarshal(c, &profiles); err != nil {
+		_, _ = logger.WriteString(fmt.Sprintf("yaml.Unmarshal(%s) failed, err: %s\n", name, err.Error()))
+		return err
+	}
+	for _, profile := range profiles {
+		if err := profile.Validate(); err != nil {
+			return fmt.Errorf("%s: %s", name, err.Error())
+		}
+	}
+
+	languageMutex.Lock()
+	defer languageMutex.Unlock()
+	for _, profile := range profiles {
+		languageProfiles[profile.Name] = profile
+	}
+	return nil
+}
+
+// LookupLanguageProfile returns the profile called name, built-in or loaded by LoadLanguageProfiles.
+//noinspection GoUnusedExportedFunction
+func LookupLanguageProfile(name string) (*LanguageProfile, error) {
+	languageMutex.RLock()
+	defer languageMutex.RUnlock()
+	profile, ok := languageProfiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown language %q", name)
+	}
+	return profile, nil
+}
+
+// DefaultSeccompProfile returns the seccomp profile of the submissions of the language lang, c if empty, for runs
+// which were not given one: those of compiled submissions are filtered unless SeccompNone is given explicitly.
+//noinspection GoUnusedExportedFunction
+func DefaultSeccompProfile(lang string) (string, error) {
+	if lang == "" {
+		lang = "c"
+	}
+	profile, err := LookupLanguageProfile(lang)
+	if err != nil {
+		return "", err
+	}
+	return profile.Seccomp, nil
+}
+
+// Validate checks that p names the compiler and the files it reads and writes, or the runtime.
+func (p *LanguageProfile) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("language profile without name")
+	}
+	if p.Source == "" {
+		return fmt.Errorf("language profile %s needs source", p.Name)
+	}
+	if p.Compiler == "" && p.Run == nil {
+		return fmt.Errorf("language profile %s needs compiler or run", p.Name)
+	}
+	if p.Compiler != "" && p.Output == "" {
+		return fmt.Errorf("language profile %s needs output of the compiler", p.Name)
+	}
+	for _, pattern := range p.Artifacts {
+		if _, err := filepath.Match(pattern, ""); err != nil || strings.Contains(pattern, "/") {
+			return fmt.Errorf("invalid artifact %q of language profile %s", pattern, p.Name)
+		}
+	}
+	if p.Timeout < 0 || p.Memory < 0 || p.Pids < 0 {
+		return fmt.Errorf("invalid limits of language profile %s, must not be negative", p.Name)
+	}
+	for _, pattern := range p.AllowedFlags {
+		if pattern == "" || pattern == "*" {
+			return fmt.Errorf("invalid allowed flag %q of language profile %s", pattern, p.Name)
+		}
+	}
+	if r := p.Run; r != nil && (r.Command == "" || r.TimeFactor < 0 || r.MemoryFactor < 0) {
+		return fmt.Errorf("language profile %s needs a run command and non-negative factors", p.Name)
+	}
+	if _, err := LoadSeccompProfile(p.Seccomp); err != nil {
+		return fmt.Errorf("invalid seccomp profile of language profile %s: %s", p.Name, err.Error())
+	}
+	return nil
+}
+
+// artifacts returns the patterns of the files the compiler of p writes for the submission.
+func (p *LanguageProfile) artifacts() []string {
+	if len(p.Artifacts) == 0 {
+		return []string{p.Output}
+	}
+	return p.Artifacts
+}
+
+// checkFlag returns an error unless flag is allowed by p and not denied.
+func (p *LanguageProfile) checkFlag(flag string) error {
+	for _, prefix := range deniedFlags {
+		if strings.HasPrefix(flag, prefix) {
+			return fmt.Errorf("flag %q is forbidden", flag)
+		}
+	}
+	// arguments of their own, e.g. the path of -include, could make the compiler read any file of the toolchain
+	if strings.HasPrefix(flag, "-") {
+		for _, pattern := range p.AllowedFlags {
+			if flag == pattern || (strings.HasSuffix(pattern, "*") && strings.HasPrefix(flag, strings.TrimSuffix(pattern, "*"))) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("flag %q is not allowed by language %s", flag, p.Name)
+}
+
+// RunConfig returns a copy of cfg running the submission by the runtime of p, with the limits multiplied.
+// cfg is returned as it is if p has no runtime.
+func (p *LanguageProfile) RunConfig(cfg *Config) *Config {
+	r := p.Run
+	if r == nil {
+		return cfg
+	}
+	factor := func(v int64, f float64) int64 {
+		if f == 0 {
+			return v
+		}
+		return int64(float64(v) * f)
+	}
+
+	runCfg := *cfg
+	runCfg.Command = r.Command
+	runCfg.Args = append(replacePlaceholders(r.Args, []string{p.Source}, p.Output, ""), cfg.Args...)
+	runCfg.Env = append(append([]string{}, r.Env...), cfg.Env...)
+	runCfg.Rootfs = append(append([]string{}, r.Rootfs...), cfg.Rootfs...)
+	runCfg.Timeout = factor(cfg.Timeout, r.TimeFactor)
+	runCfg.CPUTime = factor(cfg.CPUTime, r.TimeFactor)
+	if cfg.Limits != nil {
+		limits := *cfg.Limits
+		limits.Memory = factor(limits.Memory, r.MemoryFactor)
+		runCfg.Limits = &limits
+	}
+	return &runCfg
+}
+
+// replacePlaceholders returns a copy of args with the placeholders replaced, {source} by the first of sources.
+func replacePlaceholders(args []string, sources []string, output, std string) []string {
+	replacer := strings.NewReplacer(sourcePlaceholder, sources[0], outputPlaceholder, output, stdPlaceholder, std)
+	replaced := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == sourcesPlaceholder {
+			replaced = append(replaced, sources...)
+			continue
+		}
+		replaced = append(replaced, replacer.Replace(arg))
+	}
+	return replaced
+}
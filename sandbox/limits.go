@@ -0,0 +1,68 @@
+// +build linux
+// +build go1.12
+
+package sandbox
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Limits are the resource limits of a container, memory sizes in KB and cpu times in microseconds.
+type Limits struct {
+	Memory int64 `json:"memory"`
+	// cpuset.cpus, e.g. "0" or "0-3"
+	CPUs string `json:"cpus"`
+	// max number of tasks, threads included
+	Pids int64 `json:"pids"`
+	// cpu time per CPUPeriod, -1 for no bandwidth limit
+	CPUQuota  int64 `json:"cpuQuota"`
+	CPUPeriod int64 `json:"cpuPeriod"`
+	// kernel memory, -1 for no limit, v1 before linux 6.1 only since v2 and later kernels charge it to Memory
+	KMemory int64 `json:"kmemory"`
+	// swap on top of Memory, -1 for no limit
+	Swap int64 `json:"swap"`
+}
+
+var cpusPattern = regexp.MustCompile(`^[0-9]+(-[0-9]+)?(,[0-9]+(-[0-9]+)?)*$`)
+
+// DefaultLimits returns the limits containers always had: 10% of cpu 0, 64 tasks, 64m of kmem and swap unlimited.
+//noinspection GoUnusedExportedFunction
+func DefaultLimits() *Limits {
+	return &Limits{
+		Memory:    256,
+		CPUs:      "0",
+		Pids:      64,
+		CPUQuota:  10000,
+		CPUPeriod: 100000,
+		KMemory:   64 * 1024,
+		Swap:      -1,
+	}
+}
+
+// Validate checks the limits against what the kernel accepts.
+func (l *Limits) Validate() error {
+	if l.Memory <= 0 {
+		return fmt.Errorf("invalid memory limit %d, must be positive", l.Memory)
+	}
+	if !cpusPattern.MatchString(l.CPUs) {
+		return fmt.Errorf("invalid cpus %q, must be a list like 0-3,6", l.CPUs)
+	}
+	if l.Pids <= 0 {
+		return fmt.Errorf("invalid pids limit %d, must be positive", l.Pids)
+	}
+	// https://www.kernel.org/doc/Documentation/scheduler/sched-bwc.txt
+	if l.CPUPeriod < 1000 || l.CPUPeriod > 1000000 {
+		return fmt.Errorf("invalid cpu period %d, must be in [1000, 1000000]", l.CPUPeriod)
+	}
+	if l.CPUQuota != -1 && l.CPUQuota < 1000 {
+		return fmt.Errorf("invalid cpu quota %d, must be -1 or at least 1000", l.CPUQuota)
+	}
+	if l.KMemory != -1 && l.KMemory <= 0 {
+		return fmt.Errorf("invalid kernel memory limit %d, must be -1 or positive", l.KMemory)
+	}
+	if l.Swap != -1 && l.Swap < 0 {
+		return fmt.Errorf("invalid swap limit %d, must be -1 or not negative", l.Swap)
+	}
+	return nil
+}
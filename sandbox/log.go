@@ -0,0 +1,27 @@
+// +build linux
+// +build go1.12
+
+package sandbox
+
+import (
+	"io"
+	"os"
+)
+
+// DEBUG and error lines of this package are written to logger, os.Stderr by default.
+var logger io.StringWriter = os.Stderr
+
+type stringWriter struct {
+	io.Writer
+}
+
+func (w stringWriter) WriteString(s string) (int, error) {
+	return io.WriteString(w.Writer, s)
+}
+
+// SetLogOutput redirects the DEBUG and error lines of this package,
+// e.g. to ioutil.Discard when os.Stderr must only carry the output of the sandboxed program.
+//noinspection GoUnusedExportedFunction
+func SetLogOutput(w io.Writer) {
+	logger = stringWriter{w}
+}
@@ -10,24 +10,100 @@ import (
 	"syscall"
 )
 
+// Mount is a host path bind mounted below the new root before pivot_root, e.g. a directory of a toolchain.
+type Mount struct {
+	Source string `json:"source"`
+	// path below the new root, Source if empty
+	Target   string `json:"target"`
+	ReadOnly bool   `json:"readOnly"`
+}
+
 //noinspection GoUnusedExportedFunction
-func InitNamespace(newRoot string) error {
-	_, _ = os.Stderr.WriteString(fmt.Sprintf("DEBUG: InitNamespace(%s) starting...\n", newRoot))
+func InitNamespace(newRoot string, mounts []Mount) error {
+	_, _ = logger.WriteString(fmt.Sprintf("DEBUG: InitNamespace(%s) starting...\n", newRoot))
+
+	for _, m := range mounts {
+		if err := bindMount(newRoot, m); err != nil {
+			_, _ = logger.WriteString(fmt.Sprintf("bindMount(%s, %s) failed, err: %s\n", newRoot, m.Source, err.Error()))
+			return err
+		}
+	}
 
 	if err := pivotRoot(newRoot); err != nil {
-		_, _ = os.Stderr.WriteString(fmt.Sprintf("pivotRoot(%s) failed, err: %s\n", newRoot, err.Error()))
+		_, _ = logger.WriteString(fmt.Sprintf("pivotRoot(%s) failed, err: %s\n", newRoot, err.Error()))
 		return err
 	}
 
 	if err := syscall.Sethostname([]byte("justice")); err != nil {
-		_, _ = os.Stderr.WriteString(fmt.Sprintf("syscall.Sethostname failed, err: %s\n", err.Error()))
+		_, _ = logger.WriteString(fmt.Sprintf("syscall.Sethostname failed, err: %s\n", err.Error()))
 		return err
 	}
 
-	_, _ = os.Stderr.WriteString(fmt.Sprintf("DEBUG: InitNamespace(%s) done\n", newRoot))
+	_, _ = logger.WriteString(fmt.Sprintf("DEBUG: InitNamespace(%s) done\n", newRoot))
 	return nil
 }
 
+// the flags of a mount which a read-only remount in a user namespace must keep, statfs(2) -> mount(2)
+var lockedMountFlags = map[int64]uintptr{
+	0x2:    syscall.MS_NOSUID,
+	0x4:    syscall.MS_NODEV,
+	0x8:    syscall.MS_NOEXEC,
+	0x400:  syscall.MS_NOATIME,
+	0x800:  syscall.MS_NODIRATIME,
+	0x1000: syscall.MS_RELATIME,
+}
+
+// mountPoint creates the mount point of m below newRoot and returns its path.
+func mountPoint(newRoot string, m Mount) (string, error) {
+	target := m.Target
+	if target == "" {
+		target = m.Source
+	}
+	target = filepath.Join(newRoot, target)
+
+	info, err := os.Stat(m.Source)
+	if err != nil {
+		return "", err
+	}
+	// the mount point of a file, e.g. /dev/null, must be a file
+	if info.IsDir() {
+		err = os.MkdirAll(target, 0755)
+	} else if err = os.MkdirAll(filepath.Dir(target), 0755); err == nil {
+		var f *os.File
+		if f, err = os.OpenFile(target, os.O_CREATE|os.O_WRONLY, 0644); err == nil {
+			err = f.Close()
+		}
+	}
+	return target, err
+}
+
+func bindMount(newRoot string, m Mount) error {
+	target, err := mountPoint(newRoot, m)
+	if err != nil {
+		return err
+	}
+
+	if err := syscall.Mount(m.Source, target, "", syscall.MS_BIND|syscall.MS_REC, ""); err != nil {
+		return err
+	}
+	if !m.ReadOnly {
+		return nil
+	}
+
+	// MS_RDONLY is ignored by the bind mount itself, it takes a remount
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(target, &stat); err != nil {
+		return err
+	}
+	flags := uintptr(syscall.MS_BIND | syscall.MS_REMOUNT | syscall.MS_RDONLY)
+	for statFlag, mountFlag := range lockedMountFlags {
+		if stat.Flags&statFlag != 0 {
+			flags |= mountFlag
+		}
+	}
+	return syscall.Mount("", target, "", flags, "")
+}
+
 func pivotRoot(newRoot string) error {
 	putOld := filepath.Join(newRoot, "/.pivot_root")
 
@@ -40,19 +116,19 @@ func pivotRoot(newRoot string) error {
 	//     number of /.. to the string pointed to by put_old must yield the same directory as new_root.
 	// 4.  No other filesystem may be mounted on put_old.
 	if err := syscall.Mount(newRoot, newRoot, "", syscall.MS_BIND|syscall.MS_REC, ""); err != nil {
-		_, _ = os.Stderr.WriteString(fmt.Sprintf("syscall.Mount(%s, %s, \"\", syscall.MS_BIND|syscall.MS_REC, \"\") failed\n", newRoot, newRoot))
+		_, _ = logger.WriteString(fmt.Sprintf("syscall.Mount(%s, %s, \"\", syscall.MS_BIND|syscall.MS_REC, \"\") failed\n", newRoot, newRoot))
 		return err
 	}
 
 	// create put_old directory
 	if err := os.MkdirAll(putOld, 0700); err != nil {
-		_, _ = os.Stderr.WriteString(fmt.Sprintf("os.MkdirAll(%s, 0700) failed\n", putOld))
+		_, _ = logger.WriteString(fmt.Sprintf("os.MkdirAll(%s, 0700) failed\n", putOld))
 		return err
 	}
 
 	// call pivotRoot
 	if err := syscall.PivotRoot(newRoot, putOld); err != nil {
-		_, _ = os.Stderr.WriteString(fmt.Sprintf("syscall.PivotRoot(%s, %s) failed\n", newRoot, putOld))
+		_, _ = logger.WriteString(fmt.Sprintf("syscall.PivotRoot(%s, %s) failed\n", newRoot, putOld))
 		return err
 	}
 
@@ -60,20 +136,20 @@ func pivotRoot(newRoot string) error {
 	// or may not affect its current working directory.  It is therefore
 	// recommended to call chdir("/") immediately after pivotRoot().
 	if err := os.Chdir("/"); err != nil {
-		_, _ = os.Stderr.WriteString(fmt.Sprintf("os.Chdir(\"/\") failed\n"))
+		_, _ = logger.WriteString(fmt.Sprintf("os.Chdir(\"/\") failed\n"))
 		return err
 	}
 
 	// umount put_old, which now lives at /.pivot_root
 	putOld = "/.pivot_root"
 	if err := syscall.Unmount(putOld, syscall.MNT_DETACH); err != nil {
-		_, _ = os.Stderr.WriteString(fmt.Sprintf("syscall.Unmount(%s, syscall.MNT_DETACH) failed\n", putOld))
+		_, _ = logger.WriteString(fmt.Sprintf("syscall.Unmount(%s, syscall.MNT_DETACH) failed\n", putOld))
 		return err
 	}
 
 	// remove put_old
 	if err := os.RemoveAll(putOld); err != nil {
-		_, _ = os.Stderr.WriteString(fmt.Sprintf("os.RemoveAll(%s) failed\n", putOld))
+		_, _ = logger.WriteString(fmt.Sprintf("os.RemoveAll(%s) failed\n", putOld))
 		return err
 	}
 
@@ -0,0 +1,39 @@
+// +build linux
+// +build go1.12
+
+package sandbox
+
+import (
+	"io"
+	"sync"
+)
+
+// OutputLimiter passes at most limit bytes through to w, calls exceeded once beyond that and discards the rest,
+// so the writing process never blocks on a full pipe before it is killed.
+type OutputLimiter struct {
+	w        io.Writer
+	limit    int64
+	written  int64
+	exceeded func()
+	once     sync.Once
+}
+
+// NewOutputLimiter returns an OutputLimiter for w, limit in bytes.
+//noinspection GoUnusedExportedFunction
+func NewOutputLimiter(w io.Writer, limit int64, exceeded func()) *OutputLimiter {
+	return &OutputLimiter{w: w, limit: limit, exceeded: exceeded}
+}
+
+func (l *OutputLimiter) Write(p []byte) (int, error) {
+	if l.written+int64(len(p)) <= l.limit {
+		l.written += int64(len(p))
+		return l.w.Write(p)
+	}
+
+	if rest := l.limit - l.written; rest > 0 {
+		l.written = l.limit
+		_, _ = l.w.Write(p[:rest])
+	}
+	l.once.Do(l.exceeded)
+	return len(p), nil
+}
@@ -0,0 +1,606 @@
+// +build linux
+// +build go1.12
+
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/docker/docker/pkg/reexec"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	// justicePool receives the requests of its Sandbox through this fd, i.e. cmd.ExtraFiles[0]
+	poolFd = 3
+	// max size of a request or a response, a request holds the Config of a run
+	poolMessageSize = 1024 * 1024
+	// how long a Sandbox waits for the tasks killed by a reset to leave its cgroup, and for the answer to a ping
+	poolResetTimeout = time.Second
+	poolPingTimeout  = time.Second
+	// how much longer than the timeout of a run a Sandbox waits for its Result before it gives the sandbox up
+	poolRunGrace = 10 * time.Second
+	// prefix of the root filesystems of a Sandbox, see Config.Rootfs
+	poolRootfsPrefix = "justice-pool-"
+)
+
+func init() {
+	reexec.Register("justicePool", justicePool)
+}
+
+// poolLimits are the limits of a new Sandbox, its init runs in the cgroup and needs room for the go runtime.
+func poolLimits() *Limits {
+	limits := DefaultLimits()
+	limits.Memory = 64 * 1024
+	return limits
+}
+
+// poolRequest is what a Sandbox sends to justicePool, along with stdin, stdout and stderr of the command.
+type poolRequest struct {
+	// nil for a ping, which is answered by a Result with VerdictOK
+	Config *Config `json:"config"`
+	// mounted below Config.BaseDir by justicePool once for all runs, i.e. the root filesystem of Config.Rootfs
+	RootfsMounts []Mount `json:"rootfsMounts"`
+}
+
+// sandboxRootfs is a root filesystem of a Sandbox, see Config.Rootfs.
+type sandboxRootfs struct {
+	dir    string
+	mounts []Mount
+	// paths below dir which make up the root filesystem, whatever else a run created there is wiped by a reset
+	skeleton map[string]bool
+}
+
+// Sandbox is a container kept alive between runs: a cgroup with the namespaces of justicePool, its init, which runs
+// one command after the other, each in mount, ipc, uts and network namespaces of its own. A Sandbox is leased from a
+// Pool and must not be used by several goroutines at once.
+type Sandbox struct {
+	uid, gid int
+	cg       *CGroup
+	cmd      *exec.Cmd
+	conn     *net.UnixConn
+	// closed once justicePool exited
+	exited chan struct{}
+	// by the host paths of Config.Rootfs joined by ":"
+	rootfs map[string]*sandboxRootfs
+	// a run left the sandbox unusable, e.g. its init was killed
+	broken bool
+}
+
+// newSandbox starts justicePool in new namespaces and a new cgroup, where the command runs as uid and gid.
+func newSandbox(uid, gid int) (*Sandbox, error) {
+	cg, err := InitCGroup("pool-"+uuid.NewV4().String(), poolLimits())
+	if err != nil {
+		return nil, err
+	}
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_SEQPACKET|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		_ = cg.Destroy()
+		return nil, err
+	}
+	local, remote := os.NewFile(uintptr(fds[0]), "pool"), os.NewFile(uintptr(fds[1]), "pool")
+	defer remote.Close()
+	conn, err := net.FileConn(local)
+	_ = local.Close()
+	if err != nil {
+		_ = cg.Destroy()
+		return nil, err
+	}
+	startReader, startWriter, err := os.Pipe()
+	if err != nil {
+		_ = conn.Close()
+		_ = cg.Destroy()
+		return nil, err
+	}
+	defer startReader.Close()
+	defer startWriter.Close()
+
+	s := &Sandbox{
+		uid:    uid,
+		gid:    gid,
+		cg:     cg,
+		conn:   conn.(*net.UnixConn),
+		exited: make(chan struct{}),
+		rootfs: make(map[string]*sandboxRootfs),
+	}
+	s.cmd = reexec.Command("justicePool")
+	s.cmd.ExtraFiles = []*os.File{remote, startReader}
+	s.cmd.SysProcAttr = containerSysProcAttr(uid, gid)
+	if err := s.cmd.Start(); err != nil {
+		_ = conn.Close()
+		_ = cg.Destroy()
+		return nil, err
+	}
+	go func() {
+		_ = s.cmd.Wait()
+		close(s.exited)
+	}()
+	// justicePool waits for the start pipe, so it runs nothing outside of the cgroup
+	if err := cg.AddProcess(s.cmd.Process.Pid); err != nil {
+		_ = s.cmd.Process.Kill()
+		s.destroy()
+		return nil, err
+	}
+	_ = startWriter.Close()
+	if err := s.reset(); err != nil {
+		s.destroy()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Run executes cfg.Command in the sandbox like the Run function does in a new container, but for Config.ID, the
+// sandbox has a cgroup of its own. The uid and gid of cfg must be those of the Pool.
+func (s *Sandbox) Run(cfg *Config, stdin io.Reader, stdout, stderr io.Writer) *Result {
+	systemError := func(err error) *Result {
+		return &Result{Verdict: VerdictSystemError, Error: err.Error()}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return systemError(err)
+	}
+	if cfg.UID != s.uid || cfg.GID != s.gid {
+		return systemError(fmt.Errorf("uid %d and gid %d differ from those of the sandbox, %d and %d", cfg.UID, cfg.GID, s.uid, s.gid))
+	}
+	if s.broken {
+		return systemError(fmt.Errorf("sandbox is broken"))
+	}
+
+	req := &poolRequest{}
+	initCfg := *cfg
+	initCfg.Started, initCfg.Rootfs = nil, nil
+	if len(cfg.Rootfs) > 0 {
+		rootfs, err := s.rootfsOf(cfg.Rootfs)
+		if err != nil {
+			return systemError(err)
+		}
+		if initCfg.WorkDir == "" {
+			initCfg.WorkDir = rootfsWorkDir
+		}
+		initCfg.BaseDir = rootfs.dir
+		initCfg.Mounts = append([]Mount{{Source: cfg.BaseDir, Target: initCfg.WorkDir}}, cfg.Mounts...)
+		req.RootfsMounts = rootfs.mounts
+	}
+	req.Config = &initCfg
+	if err := s.cg.SetLimits(cfg.Limits); err != nil {
+		return systemError(err)
+	}
+
+	// the command gets files, readers and writers are copied through pipes like exec.Cmd does
+	var files, owned []*os.File
+	var copying sync.WaitGroup
+	closeOwned := func() {
+		for _, f := range owned {
+			_ = f.Close()
+		}
+		owned = nil
+	}
+	defer closeOwned()
+	for i, std := range []interface{}{stdin, stdout, stderr} {
+		if f, ok := std.(*os.File); ok && f != nil {
+			files = append(files, f)
+			continue
+		}
+		if f, ok := std.(*os.File); std == nil || ok && f == nil {
+			null, err := os.OpenFile(os.DevNull, os.O_RDWR, 0)
+			if err != nil {
+				return systemError(err)
+			}
+			files, owned = append(files, null), append(owned, null)
+			continue
+		}
+		r, w, err := os.Pipe()
+		if err != nil {
+			return systemError(err)
+		}
+		if i == 0 {
+			files, owned = append(files, r), append(owned, r)
+			// the copy ends at the end of stdin, or once the command and justicePool closed the other end
+			go func(reader io.Reader) {
+				_, _ = io.Copy(w, reader)
+				_ = w.Close()
+			}(std.(io.Reader))
+			continue
+		}
+		files, owned = append(files, w), append(owned, w)
+		copying.Add(1)
+		go func(writer io.Writer) {
+			defer copying.Done()
+			_, _ = io.Copy(writer, r)
+			_ = r.Close()
+		}(std.(io.Writer))
+	}
+
+	spec, err := json.Marshal(req)
+	if err != nil {
+		return systemError(err)
+	}
+	rights := syscall.UnixRights(int(files[0].Fd()), int(files[1].Fd()), int(files[2].Fd()))
+	startTime := time.Now()
+	if _, _, err := s.conn.WriteMsgUnix(spec, rights, nil); err != nil {
+		s.broken = true
+		return systemError(err)
+	}
+	// justicePool holds the files now, the copies end once it and the command closed them
+	closeOwned()
+	if cfg.Started != nil {
+		cfg.Started(s.cg)
+	}
+
+	initPid := s.cmd.Process.Pid
+	var cpuKilled int32
+	stopWatch := watchCPUTime(s.cg, cfg.CPUTime, func() {
+		atomic.StoreInt32(&cpuKilled, 1)
+		_ = s.cg.KillExcept(initPid)
+	})
+	record := make([]byte, poolMessageSize)
+	_ = s.conn.SetReadDeadline(time.Now().Add(time.Duration(cfg.Timeout)*time.Millisecond + poolRunGrace))
+	n, _, _, _, readErr := s.conn.ReadMsgUnix(record, nil)
+	_ = s.conn.SetReadDeadline(time.Time{})
+	stopWatch()
+	if readErr != nil || n == 0 {
+		// justicePool died or hangs, the sandbox is given up, which also ends the copies
+		s.broken = true
+		_ = s.cg.Kill()
+	}
+	copying.Wait()
+	wallTime := time.Since(startTime)
+
+	result, decodeErr := UnmarshalResult(record[:n])
+	if decodeErr != nil {
+		result = &Result{Verdict: VerdictSystemError, Error: decodeErr.Error(), WallTime: int64(wallTime / time.Millisecond)}
+		if readErr != nil {
+			result.Error = readErr.Error()
+		}
+	}
+	oomKilled := false
+	if stats, err := s.cg.Stats(); err == nil {
+		oomKilled = stats.OOMKills > 0
+	}
+	accountCGroup(result, cfg, s.cg, oomKilled, atomic.LoadInt32(&cpuKilled) == 1, decodeErr != nil)
+	return result
+}
+
+// rootfsOf returns the root filesystem of paths, which is created on first use. Its mounts are made by justicePool
+// with the first run and kept for the following ones.
+func (s *Sandbox) rootfsOf(paths []string) (*sandboxRootfs, error) {
+	key := strings.Join(paths, ":")
+	if rootfs, ok := s.rootfs[key]; ok {
+		return rootfs, nil
+	}
+	dir, mounts, err := newRootfs(poolRootfsPrefix, paths)
+	if err != nil {
+		return nil, err
+	}
+	// the mount points are made here, so that the skeleton has them all
+	for _, m := range mounts {
+		if _, err := mountPoint(dir, m); err != nil {
+			_ = os.RemoveAll(dir)
+			return nil, err
+		}
+	}
+	rootfs := &sandboxRootfs{dir: dir, mounts: mounts, skeleton: make(map[string]bool)}
+	_ = filepath.Walk(dir, func(path string, _ os.FileInfo, err error) error {
+		if err == nil {
+			rootfs.skeleton[path] = true
+		}
+		return nil
+	})
+	s.rootfs[key] = rootfs
+	return rootfs, nil
+}
+
+// reset prepares the sandbox for the next run: it kills the tasks left but justicePool, starts the accounting over and wipes what the runs wrote into the root filesystems.
+func (s *Sandbox) reset() error {
+	if s.broken {
+		return fmt.Errorf("sandbox is broken")
+	}
+	initPid := s.cmd.Process.Pid
+	deadline := time.Now().Add(poolResetTimeout)
+	for {
+		pids, err := s.cg.Procs()
+		if err != nil {
+			return err
+		}
+		if len(pids) == 1 && pids[0] == initPid {
+			break
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("tasks %v of the sandbox are still alive", pids)
+		}
+		_ = s.cg.KillExcept(initPid)
+		time.Sleep(10 * time.Millisecond)
+	}
+	// the limits stay until the next run sets its own, lowering them now could fail while the cache of the run is charged
+	if err := s.cg.Reset(); err != nil {
+		return err
+	}
+	// the host does not see the mounts of justicePool, only the mount points below the root filesystems
+	for _, rootfs := range s.rootfs {
+		var wipe []string
+		err := filepath.Walk(rootfs.dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !rootfs.skeleton[path] {
+				wipe = append(wipe, path)
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, path := range wipe {
+			if err := os.RemoveAll(path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// healthy checks that justicePool is alive and answers a ping, and that it is alone in the cgroup.
+func (s *Sandbox) healthy() error {
+	if s.broken {
+		return fmt.Errorf("sandbox is broken")
+	}
+	select {
+	case <-s.exited:
+		return fmt.Errorf("init of the sandbox exited")
+	default:
+	}
+	pids, err := s.cg.Procs()
+	if err != nil {
+		return err
+	}
+	if len(pids) != 1 || pids[0] != s.cmd.Process.Pid {
+		return fmt.Errorf("tasks %v in the cgroup of the sandbox", pids)
+	}
+	for _, rootfs := range s.rootfs {
+		if _, err := os.Stat(rootfs.dir); err != nil {
+			return err
+		}
+	}
+
+	ping, _ := json.Marshal(&poolRequest{})
+	_ = s.conn.SetDeadline(time.Now().Add(poolPingTimeout))
+	defer s.conn.SetDeadline(time.Time{})
+	if _, err := s.conn.Write(ping); err != nil {
+		return err
+	}
+	record := make([]byte, poolMessageSize)
+	n, err := s.conn.Read(record)
+	if err != nil {
+		return err
+	}
+	if result, err := UnmarshalResult(record[:n]); err != nil || result.Verdict != VerdictOK {
+		return fmt.Errorf("invalid answer %q to a ping", record[:n])
+	}
+	return nil
+}
+
+// destroy kills justicePool with the cgroup and removes the root filesystems.
+func (s *Sandbox) destroy() {
+	s.broken = true
+	// justicePool exits at the end of its requests
+	_ = s.conn.Close()
+	_ = s.cg.Destroy()
+	select {
+	case <-s.exited:
+	case <-time.After(cgDestroyTimeout):
+		_ = s.cmd.Process.Kill()
+	}
+	// the mounts were in the namespaces of justicePool, which are gone
+	for _, rootfs := range s.rootfs {
+		_ = os.RemoveAll(rootfs.dir)
+	}
+}
+
+// Pool keeps pre-warmed Sandboxes of a uid and gid, which are leased for runs and returned.
+// Sandboxes are reset when returned and checked when leased again, broken ones are replaced by new ones.
+type Pool struct {
+	uid, gid int
+	size     int
+	// idle sandboxes, nil for one which is created once leased
+	idle chan *Sandbox
+
+	mutex sync.Mutex
+	// closed by Close
+	done chan struct{}
+}
+
+// NewPool creates size sandboxes running commands as uid and gid.
+//noinspection GoUnusedExportedFunction
+func NewPool(size, uid, gid int) (*Pool, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid size %d of the pool, must be positive", size)
+	}
+	p := &Pool{uid: uid, gid: gid, size: size, idle: make(chan *Sandbox, size), done: make(chan struct{})}
+	for i := 0; i < size; i++ {
+		s, err := newSandbox(uid, gid)
+		if err != nil {
+			for j := 0; j < i; j++ {
+				(<-p.idle).destroy()
+			}
+			return nil, err
+		}
+		p.idle <- s
+	}
+	return p, nil
+}
+
+// Lease waits for an idle sandbox, which is checked first and replaced if it is broken.
+func (p *Pool) Lease(ctx context.Context) (*Sandbox, error) {
+	var s *Sandbox
+	select {
+	case <-p.done:
+		return nil, fmt.Errorf("pool is closed")
+	case s = <-p.idle:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if p.isClosed() {
+		// Close waits for it
+		p.idle <- s
+		return nil, fmt.Errorf("pool is closed")
+	}
+	if s != nil {
+		err := s.healthy()
+		if err == nil {
+			return s, nil
+		}
+		_, _ = logger.WriteString(fmt.Sprintf("DEBUG: sandbox %s is replaced, err: %s\n", s.cg.ID, err.Error()))
+		s.destroy()
+	}
+	s, err := newSandbox(p.uid, p.gid)
+	if err != nil {
+		p.idle <- nil
+		return nil, err
+	}
+	return s, nil
+}
+
+// Return resets s for the next lease, or destroys it if that fails or the pool is closed.
+func (p *Pool) Return(s *Sandbox) {
+	if err := s.reset(); err != nil || p.isClosed() {
+		s.destroy()
+		s = nil
+	}
+	p.idle <- s
+}
+
+// Run executes cfg in a leased sandbox, or in a new container by Run if cfg is of another uid or gid.
+func (p *Pool) Run(ctx context.Context, cfg *Config, stdin io.Reader, stdout, stderr io.Writer) *Result {
+	if cfg.UID != p.uid || cfg.GID != p.gid {
+		return Run(cfg, stdin, stdout, stderr)
+	}
+	s, err := p.Lease(ctx)
+	if err != nil {
+		return &Result{Verdict: VerdictSystemError, Error: err.Error()}
+	}
+	defer p.Return(s)
+	return s.Run(cfg, stdin, stdout, stderr)
+}
+
+// Close destroys the sandboxes, it waits for the leased ones to be returned.
+func (p *Pool) Close() {
+	p.mutex.Lock()
+	if p.isClosed() {
+		p.mutex.Unlock()
+		return
+	}
+	close(p.done)
+	p.mutex.Unlock()
+	for i := 0; i < p.size; i++ {
+		if s := <-p.idle; s != nil {
+			s.destroy()
+		}
+	}
+}
+
+func (p *Pool) isClosed() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// justicePool is the init of the pid namespace of a Sandbox. It runs the requests one after the other by runExec,
+// each in new namespaces but the pid one, and kills and reaps whatever a command left behind.
+func justicePool() {
+	// wait until the Sandbox has put this process into the cgroup
+	syscall.CloseOnExec(startFd)
+	startPipe := os.NewFile(startFd, "start")
+	_, _ = ioutil.ReadAll(startPipe)
+	_ = startPipe.Close()
+	SetLogOutput(ioutil.Discard)
+
+	syscall.CloseOnExec(poolFd)
+	conn, err := net.FileConn(os.NewFile(poolFd, "pool"))
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	pool := conn.(*net.UnixConn)
+
+	// neither the mounts of the root filesystems nor those of a run propagate to the host
+	if err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+		return
+	}
+
+	mounted := make(map[string]bool)
+	record := make([]byte, poolMessageSize)
+	oob := make([]byte, syscall.CmsgSpace(3*4))
+	for {
+		n, oobn, _, _, err := pool.ReadMsgUnix(record, oob)
+		if err != nil || n == 0 {
+			// the Sandbox is destroyed
+			return
+		}
+		var files []*os.File
+		if messages, err := syscall.ParseSocketControlMessage(oob[:oobn]); err == nil {
+			for _, m := range messages {
+				fds, _ := syscall.ParseUnixRights(&m)
+				for _, fd := range fds {
+					syscall.CloseOnExec(fd)
+					files = append(files, os.NewFile(uintptr(fd), "std"))
+				}
+			}
+		}
+
+		result := func() *Result {
+			req := &poolRequest{}
+			if err := json.Unmarshal(record[:n], req); err != nil {
+				return &Result{Verdict: VerdictSystemError, Error: err.Error()}
+			}
+			if req.Config == nil {
+				return &Result{Verdict: VerdictOK}
+			}
+			if len(files) != 3 {
+				return &Result{Verdict: VerdictSystemError, Error: fmt.Sprintf("got %d files instead of stdin, stdout and stderr", len(files))}
+			}
+			if len(req.RootfsMounts) > 0 && !mounted[req.Config.BaseDir] {
+				for _, m := range req.RootfsMounts {
+					if err := bindMount(req.Config.BaseDir, m); err != nil {
+						return &Result{Verdict: VerdictSystemError, Error: err.Error()}
+					}
+				}
+				mounted[req.Config.BaseDir] = true
+			}
+			// the ipc objects, e.g. SysV shared memory, and the network of a run go with its namespaces
+			return runExec(req.Config, files[0], files[1], files[2], syscall.CLONE_NEWNS|syscall.CLONE_NEWIPC|syscall.CLONE_NEWUTS|syscall.CLONE_NEWNET)
+		}()
+		for _, f := range files {
+			_ = f.Close()
+		}
+
+		// kill(-1) spares the init only, the orphans of the command are reaped here
+		_ = syscall.Kill(-1, syscall.SIGKILL)
+		for {
+			if _, err := syscall.Wait4(-1, nil, 0, nil); err == syscall.ECHILD {
+				break
+			}
+		}
+		if _, err := pool.Write(MarshalResult(result)); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// +build linux
+// +build go1.12
+
+package sandbox
+
+import (
+	"encoding/json"
+)
+
+type Verdict string
+
+const (
+	VerdictOK                  Verdict = "OK"
+	VerdictTimeLimitExceeded   Verdict = "TLE"
+	VerdictMemoryLimitExceeded Verdict = "MLE"
+	VerdictRuntimeError        Verdict = "RE"
+	VerdictOutputLimitExceeded Verdict = "OLE"
+	VerdictRestrictedFunction  Verdict = "RF"
+	VerdictSystemError         Verdict = "SE"
+
+	// verdicts of an OK run judged by a Judge
+	VerdictAccepted          Verdict = "AC"
+	VerdictWrongAnswer       Verdict = "WA"
+	VerdictPresentationError Verdict = "PE"
+	// both the command and the interactor were blocked
+	VerdictIdleLimitExceeded Verdict = "ILE"
+
+	// verdicts of a compilation, see CompileResult
+	VerdictCompileError         Verdict = "CE"
+	VerdictCompileLimitExceeded Verdict = "CLE"
+)
+
+// Result is the machine-readable record of one run in the sandbox.
+// Times are in milliseconds and memory is in KB, the same units as the flags of clike_container.
+// CPUTime and Memory are accounted by the cgroup, i.e. of all processes and threads, WallTime is of the command only.
+type Result struct {
+	// name of the test case in batch mode
+	Case        string  `json:"case,omitempty"`
+	Verdict     Verdict `json:"verdict"`
+	ExitCode    int     `json:"exitCode"`
+	Signal      int     `json:"signal"`
+	CPUTime     int64   `json:"cpuTime"`
+	WallTime    int64   `json:"wallTime"`
+	Memory      int64   `json:"memory"`
+	MemoryLimit int64   `json:"memoryLimit"`
+	OOMKilled   bool    `json:"oomKilled"`
+	// who killed the command, empty if it exited by itself
+	Killed KillReason `json:"killed,omitempty"`
+	Error  string     `json:"error,omitempty"`
+	// first difference of the output from the expected output with VerdictWrongAnswer
+	Difference *Difference `json:"difference,omitempty"`
+	// message of the checker program of a SpecialJudge
+	Message string `json:"message,omitempty"`
+	// result of the interactor of an Interaction
+	Interactor *Result `json:"interactor,omitempty"`
+}
+
+// MarshalResult encodes r as a single line of JSON.
+//noinspection GoUnusedExportedFunction
+func MarshalResult(r *Result) []byte {
+	b, _ := json.Marshal(r)
+	return append(b, '\n')
+}
+
+// UnmarshalResult decodes a record produced by MarshalResult.
+//noinspection GoUnusedExportedFunction
+func UnmarshalResult(b []byte) (*Result, error) {
+	r := &Result{}
+	if err := json.Unmarshal(b, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
@@ -0,0 +1,62 @@
+// +build linux
+// +build go1.12
+
+package sandbox
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// working directory inside a root filesystem of newRootfs, e.g. the scratch directory of the compiler
+const rootfsWorkDir = "/code"
+
+// devices mounted into every root filesystem of newRootfs, e.g. gcc writes /dev/null
+var rootfsDevices = []string{"/dev/null", "/dev/zero", "/dev/random", "/dev/urandom"}
+
+// newRootfs creates an empty root filesystem owned by root in a new temporary directory, and returns the mounts of
+// the host paths, read-only at the same path, and of rootfsDevices. Missing paths are skipped, symlinks are copied
+// as they are, e.g. /lib -> usr/lib of a merged /usr.
+func newRootfs(prefix string, paths []string) (string, []Mount, error) {
+	rootfs, err := ioutil.TempDir("", prefix)
+	if err != nil {
+		return "", nil, err
+	}
+	fail := func(err error) (string, []Mount, error) {
+		_ = os.RemoveAll(rootfs)
+		return "", nil, err
+	}
+	if err := os.Chmod(rootfs, 0755); err != nil {
+		return fail(err)
+	}
+
+	var mounts []Mount
+	for _, path := range paths {
+		info, err := os.Lstat(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fail(err)
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			link, err := os.Readlink(path)
+			if err == nil {
+				err = os.MkdirAll(filepath.Dir(filepath.Join(rootfs, path)), 0755)
+			}
+			if err == nil {
+				err = os.Symlink(link, filepath.Join(rootfs, path))
+			}
+			if err != nil {
+				return fail(err)
+			}
+			continue
+		}
+		mounts = append(mounts, Mount{Source: path, ReadOnly: true})
+	}
+	for _, device := range rootfsDevices {
+		mounts = append(mounts, Mount{Source: device})
+	}
+	return rootfs, mounts, nil
+}
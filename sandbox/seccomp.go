@@ -0,0 +1,197 @@
+// +build linux
+// +build go1.12
+
+package sandbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"syscall"
+	"unsafe"
+)
+
+// https://www.kernel.org/doc/Documentation/prctl/seccomp_filter.txt
+const (
+	prSetNoNewPrivs   = 38
+	seccompModeFilter = 2
+
+	seccompRetKillProcess = 0x80000000
+	seccompRetErrno       = 0x00050000
+	seccompRetAllow       = 0x7fff0000
+
+	// offsetof(struct seccomp_data, ...)
+	seccompDataNr   = 0
+	seccompDataArch = 4
+	seccompDataArg0 = 16
+
+	// syscall numbers of the x32 ABI share AUDIT_ARCH_X86_64 and have this bit set
+	x32SyscallBit = 0x40000000
+)
+
+// SeccompProfile is an allowlist of syscalls, any other syscall kills the whole process with SIGSYS.
+type SeccompProfile struct {
+	Name string `json:"name"`
+	// syscalls allowed unconditionally
+	Allow []string `json:"allow"`
+	// syscalls failing with ENOSYS instead of killing the process, e.g. clone3 to make glibc fall back to clone
+	Errno []string `json:"errno"`
+	// clone is allowed with CLONE_THREAD only, i.e. threads but no fork
+	Threads bool `json:"threads"`
+}
+
+// syscalls of a static glibc binary, single or multi threaded, doing stdio and reading files
+var clikeSyscalls = []string{
+	"read", "write", "readv", "writev", "pread64", "pwrite64", "lseek", "close",
+	"open", "openat", "fstat", "stat", "lstat", "newfstatat", "statx", "ioctl", "fcntl",
+	"dup", "dup2", "dup3", "access", "faccessat", "faccessat2", "readlink", "readlinkat", "getcwd",
+	"brk", "mmap", "mprotect", "munmap", "mremap", "madvise",
+	"rt_sigaction", "rt_sigprocmask", "rt_sigreturn", "sigaltstack",
+	"arch_prctl", "set_tid_address", "set_robust_list", "get_robust_list", "rseq",
+	"prlimit64", "getrlimit", "getrusage", "sysinfo", "uname", "getrandom", "times",
+	"futex", "sched_yield", "sched_getaffinity", "nanosleep", "clock_nanosleep",
+	"clock_gettime", "clock_getres", "gettimeofday", "time",
+	"getpid", "gettid", "tgkill", "getuid", "geteuid", "getgid", "getegid",
+	"execve", "exit", "exit_group",
+}
+
+// SeccompNone is the name which turns syscall filtering off where a seccomp profile is chosen by default,
+// see DefaultSeccompProfile.
+const SeccompNone = "none"
+
+var builtinSeccompProfiles = map[string]*SeccompProfile{
+	"c":   {Name: "c", Allow: clikeSyscalls, Errno: []string{"clone3"}, Threads: true},
+	"cpp": {Name: "cpp", Allow: clikeSyscalls, Errno: []string{"clone3"}, Threads: true},
+}
+
+// LoadSeccompProfile returns the built-in profile called name, or reads a json SeccompProfile from the file name.
+// An empty name or SeccompNone means no syscall filtering at all and returns nil.
+//noinspection GoUnusedExportedFunction
+func LoadSeccompProfile(name string) (*SeccompProfile, error) {
+	if name == "" || name == SeccompNone {
+		return nil, nil
+	}
+	if profile, ok := builtinSeccompProfiles[name]; ok {
+		return profile, nil
+	}
+
+	c, err := ioutil.ReadFile(name)
+	if err != nil {
+		_, _ = logger.WriteString(fmt.Sprintf("ioutil.ReadFile(%s) failed, err: %s\n", name, err.Error()))
+		return nil, err
+	}
+	profile := &SeccompProfile{}
+	if err := json.Unmarshal(c, profile); err != nil {
+		_, _ = logger.WriteString(fmt.Sprintf("json.Unmarshal(%s) failed, err: %s\n", name, err.Error()))
+		return nil, err
+	}
+
+	// resolve syscall names right now rather than after pivot_root
+	if _, err := profile.filter(); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// SeccompPolicy restricts the seccomp profiles the clients of a daemon running as root may choose for their runs.
+type SeccompPolicy struct {
+	// the json profiles are below it, only the built-in ones are allowed if empty
+	Dir HostDir
+	// whether SeccompNone may turn syscall filtering off
+	AllowNone bool
+}
+
+// Resolve returns the profile called name to run with: "" for the default one of the language, a built-in profile,
+// SeccompNone if allowed, or the abs path of a file below Dir.
+func (p *SeccompPolicy) Resolve(name string) (string, error) {
+	if _, ok := builtinSeccompProfiles[name]; ok || name == "" {
+		return name, nil
+	}
+	if name == SeccompNone {
+		if !p.AllowNone {
+			return "", fmt.Errorf("seccomp profile %s is not allowed", SeccompNone)
+		}
+		return name, nil
+	}
+	if p.Dir == "" {
+		return "", fmt.Errorf("invalid seccomp profile %q, only built-in profiles are allowed", name)
+	}
+	path, err := p.Dir.Resolve(name)
+	if err != nil {
+		return "", fmt.Errorf("invalid seccomp profile %q, err: %s", name, err.Error())
+	}
+	return path, nil
+}
+
+// filter compiles the profile into a classic BPF program for SECCOMP_MODE_FILTER.
+func (p *SeccompProfile) filter() ([]syscall.SockFilter, error) {
+	if len(syscallNumbers) == 0 {
+		return nil, fmt.Errorf("seccomp is not supported on this architecture")
+	}
+
+	stmt := func(code uint16, k uint32) syscall.SockFilter {
+		return syscall.SockFilter{Code: code, K: k}
+	}
+	jump := func(code uint16, k uint32, jt, jf uint8) syscall.SockFilter {
+		return syscall.SockFilter{Code: code, Jt: jt, Jf: jf, K: k}
+	}
+
+	filter := []syscall.SockFilter{
+		// kill anything not issued through the native ABI
+		stmt(syscall.BPF_LD|syscall.BPF_W|syscall.BPF_ABS, seccompDataArch),
+		jump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, auditArch, 1, 0),
+		stmt(syscall.BPF_RET|syscall.BPF_K, seccompRetKillProcess),
+		stmt(syscall.BPF_LD|syscall.BPF_W|syscall.BPF_ABS, seccompDataNr),
+		jump(syscall.BPF_JMP|syscall.BPF_JGE|syscall.BPF_K, x32SyscallBit, 0, 1),
+		stmt(syscall.BPF_RET|syscall.BPF_K, seccompRetKillProcess),
+	}
+
+	for _, name := range p.Allow {
+		nr, ok := syscallNumbers[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown syscall %s in seccomp profile %s", name, p.Name)
+		}
+		filter = append(filter,
+			jump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, nr, 0, 1),
+			stmt(syscall.BPF_RET|syscall.BPF_K, seccompRetAllow),
+		)
+	}
+
+	for _, name := range p.Errno {
+		nr, ok := syscallNumbers[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown syscall %s in seccomp profile %s", name, p.Name)
+		}
+		filter = append(filter,
+			jump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, nr, 0, 1),
+			stmt(syscall.BPF_RET|syscall.BPF_K, seccompRetErrno|uint32(syscall.ENOSYS)),
+		)
+	}
+
+	if p.Threads {
+		filter = append(filter,
+			jump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, syscallNumbers["clone"], 0, 3),
+			// the lower 32 bits of the flags, x86_64 is little-endian
+			stmt(syscall.BPF_LD|syscall.BPF_W|syscall.BPF_ABS, seccompDataArg0),
+			jump(syscall.BPF_JMP|syscall.BPF_JSET|syscall.BPF_K, syscall.CLONE_THREAD, 0, 1),
+			stmt(syscall.BPF_RET|syscall.BPF_K, seccompRetAllow),
+		)
+	}
+
+	return append(filter, stmt(syscall.BPF_RET|syscall.BPF_K, seccompRetKillProcess)), nil
+}
+
+// installSeccomp installs filter on the calling thread only, which must be locked by runtime.LockOSThread().
+// No Go code may run between installSeccomp and execve(), since the Go runtime needs more syscalls than the profile.
+func installSeccomp(filter []syscall.SockFilter) syscall.Errno {
+	prog := syscall.SockFprog{Len: uint16(len(filter)), Filter: &filter[0]}
+
+	// required to install a filter without CAP_SYS_ADMIN
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0); errno != 0 {
+		return errno
+	}
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, syscall.PR_SET_SECCOMP, seccompModeFilter, uintptr(unsafe.Pointer(&prog))); errno != 0 {
+		return errno
+	}
+	return 0
+}
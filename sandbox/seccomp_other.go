@@ -0,0 +1,9 @@
+// +build linux,!amd64
+// +build go1.12
+
+package sandbox
+
+const auditArch = 0
+
+// seccomp profiles are only resolved on amd64 so far
+var syscallNumbers = map[string]uint32{}
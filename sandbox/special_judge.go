@@ -0,0 +1,150 @@
+// +build linux
+// +build go1.12
+
+package sandbox
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// exit codes of testlib checkers, any other exit code means the checker itself failed
+const (
+	checkerAccepted          = 0
+	checkerWrongAnswer       = 1
+	checkerPresentationError = 2
+)
+
+// the checker runs as `./checker input.txt output.txt answer.txt` in its own basedir
+const (
+	checkerCommand = "./checker"
+	checkerInput   = "input.txt"
+	checkerOutput  = "output.txt"
+	checkerAnswer  = "answer.txt"
+)
+
+// at most this many bytes of the message of the checker are kept
+const checkerMessageLimit = 1024
+
+// SpecialJudge runs a checker program of the problem setter in a sandbox of its own, testlib style,
+// i.e. as `./checker input.txt output.txt answer.txt` with the message on stderr.
+type SpecialJudge struct {
+	// path of the checker on the host, it must be a static binary as it runs alone in a fresh basedir
+	Checker string
+	// limits of the checker, BaseDir, Command and Args are overwritten
+	Config *Config
+}
+
+// Judge sets the verdict of an OK result to VerdictAccepted, VerdictWrongAnswer or VerdictPresentationError
+// by the exit code of the checker, and the message to what the checker printed to stderr.
+func (j *SpecialJudge) Judge(result *Result, input, output, expected io.Reader) {
+	if result.Verdict != VerdictOK {
+		return
+	}
+
+	checked, message, err := j.run(input, output, expected)
+	if err != nil {
+		result.Verdict, result.Error = VerdictSystemError, err.Error()
+		return
+	}
+	result.Message = message
+	if result.Verdict, err = testlibVerdict("checker", checked); err != nil {
+		result.Error = err.Error()
+	}
+}
+
+// run copies the checker and its files into a fresh basedir and runs it there.
+func (j *SpecialJudge) run(input, output, expected io.Reader) (*Result, string, error) {
+	dir, err := newJudgeDir(j.Checker, checkerCommand,
+		judgeFile{checkerInput, input, 0644},
+		judgeFile{checkerOutput, output, 0644},
+		judgeFile{checkerAnswer, expected, 0644},
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	cfg := *j.Config
+	cfg.BaseDir, cfg.Command, cfg.Args = dir, checkerCommand, []string{checkerInput, checkerOutput, checkerAnswer}
+	var stderr bytes.Buffer
+	checked := Run(&cfg, nil, ioutil.Discard, NewOutputLimiter(&stderr, checkerMessageLimit, func() {}))
+	if checked.Verdict == VerdictSystemError {
+		return nil, "", fmt.Errorf("checker failed: %s", checked.Error)
+	}
+	return checked, strings.TrimSpace(stderr.String()), nil
+}
+
+// testlibVerdict maps the exit code of a testlib checker or interactor called name
+// to VerdictAccepted, VerdictWrongAnswer or VerdictPresentationError, anything else is a failure of the program itself.
+func testlibVerdict(name string, r *Result) (Verdict, error) {
+	switch {
+	case r.Verdict == VerdictOK && r.ExitCode == checkerAccepted:
+		return VerdictAccepted, nil
+	case r.Verdict == VerdictRuntimeError && r.Signal == 0 && r.ExitCode == checkerWrongAnswer:
+		return VerdictWrongAnswer, nil
+	case r.Verdict == VerdictRuntimeError && r.Signal == 0 && r.ExitCode == checkerPresentationError:
+		return VerdictPresentationError, nil
+	default:
+		return VerdictSystemError, fmt.Errorf("%s failed with %s, exit code %d, signal %d: %s",
+			name, r.Verdict, r.ExitCode, r.Signal, r.Error)
+	}
+}
+
+// judgeFile is a file in the basedir of a checker or an interactor.
+type judgeFile struct {
+	name string
+	r    io.Reader
+	perm os.FileMode
+}
+
+// newJudgeDir creates a fresh basedir holding the program at path as command, and files.
+// The caller removes the basedir.
+func newJudgeDir(path, command string, files ...judgeFile) (string, error) {
+	program, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer program.Close()
+
+	dir, err := ioutil.TempDir("", "justice-judge-")
+	if err != nil {
+		return "", err
+	}
+	// the program runs as the unprivileged user of its config
+	if err := os.Chmod(dir, 0755); err != nil {
+		_ = os.RemoveAll(dir)
+		return "", err
+	}
+
+	for _, file := range append([]judgeFile{{command, program, 0755}}, files...) {
+		if err := copyFile(filepath.Join(dir, file.name), file.r, file.perm); err != nil {
+			_ = os.RemoveAll(dir)
+			return "", err
+		}
+	}
+	return dir, nil
+}
+
+func copyFile(name string, r io.Reader, perm os.FileMode) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	// the umask must not take away the permissions of the unprivileged user
+	return os.Chmod(name, perm)
+}
@@ -0,0 +1,145 @@
+// +build linux
+// +build go1.12
+
+package sandbox
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// KillReason tells why a Supervisor killed its process.
+type KillReason string
+
+const (
+	// the process exited by itself, or was killed by others, e.g. by a signal or the OOM killer
+	KillNone KillReason = ""
+	// the deadline of the context of Wait passed
+	KillTimeout KillReason = "timeout"
+	// the context of Wait was canceled
+	KillCanceled KillReason = "canceled"
+	// by Kill, e.g. beyond the cpu time or the output limit
+	KillCPUTime     KillReason = "cpu time"
+	KillOutputLimit KillReason = "output limit"
+
+	// reasons of Result.Killed beside the above: a signal not sent by a Supervisor, e.g. SIGSEGV or SIGXFSZ, and the
+	// OOM killer of the cgroup
+	KillSignal KillReason = "signal"
+	KillOOM    KillReason = "oom"
+)
+
+// Exit is how the process of a Supervisor ended.
+type Exit struct {
+	// why the Supervisor killed the process, KillNone if it did not
+	Reason KillReason
+	// a task of the cgroup of the Supervisor was killed by the OOM killer
+	OOMKilled bool
+	// the process was killed by Signal, 0 if it exited with ExitCode
+	ExitCode int
+	Signal   syscall.Signal
+	// since Wait was called, i.e. since the deadline started
+	WallTime time.Duration
+	State    *os.ProcessState
+	// of cmd.Wait()
+	Err error
+}
+
+// Supervisor starts a command, kills it once the context of Wait is done and reports why it ended.
+// Kill may be called from any goroutine at any time, only the first reason while the process runs counts.
+type Supervisor struct {
+	cmd *exec.Cmd
+	// kills the process and its children, e.g. CGroup.Kill
+	kill func()
+	// nil if the process is not in a cgroup of its own
+	cg *CGroup
+
+	mutex   sync.Mutex
+	started bool
+	exited  bool
+	reason  KillReason
+	// the error of cmd.Wait(), once the process is reaped
+	waited chan error
+}
+
+// NewSupervisor returns a Supervisor of cmd, kill defaults to killing the process of cmd.
+//noinspection GoUnusedExportedFunction
+func NewSupervisor(cmd *exec.Cmd, kill func(), cg *CGroup) *Supervisor {
+	s := &Supervisor{cmd: cmd, kill: kill, cg: cg, waited: make(chan error, 1)}
+	if s.kill == nil {
+		s.kill = func() {
+			_ = cmd.Process.Kill()
+		}
+	}
+	return s
+}
+
+// Start starts the command and reaps it in the background. The goroutine is started here rather than by Wait: once
+// the command runs, e.g. a fork bomb may take every pid of the cgroup and a new thread of the runtime, which a new
+// goroutine may need, would abort the process.
+func (s *Supervisor) Start() error {
+	if err := s.cmd.Start(); err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	s.started = true
+	s.mutex.Unlock()
+	go func() {
+		err := s.cmd.Wait()
+		s.mutex.Lock()
+		s.exited = true
+		s.mutex.Unlock()
+		s.waited <- err
+	}()
+	return nil
+}
+
+// Kill kills the process for reason, unless it is not started or already reaped.
+func (s *Supervisor) Kill(reason KillReason) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !s.started || s.exited {
+		return
+	}
+	if s.reason == KillNone {
+		s.reason = reason
+	}
+	s.kill()
+}
+
+// Wait waits until the process exits, it is killed with KillTimeout or KillCanceled once ctx is done.
+// It must be called once after a successful Start.
+func (s *Supervisor) Wait(ctx context.Context) *Exit {
+	start := time.Now()
+	var err error
+	select {
+	case err = <-s.waited:
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			s.Kill(KillTimeout)
+		} else {
+			s.Kill(KillCanceled)
+		}
+		err = <-s.waited
+	}
+
+	s.mutex.Lock()
+	exit := &Exit{Reason: s.reason, WallTime: time.Since(start), State: s.cmd.ProcessState, Err: err}
+	s.mutex.Unlock()
+	if exit.State != nil {
+		status := exit.State.Sys().(syscall.WaitStatus)
+		exit.ExitCode = status.ExitStatus()
+		if status.Signaled() {
+			exit.Signal = status.Signal()
+		}
+	}
+	if s.cg != nil {
+		if stats, err := s.cg.Stats(); err == nil {
+			exit.OOMKilled = stats.OOMKills > 0
+		}
+	}
+	return exit
+}
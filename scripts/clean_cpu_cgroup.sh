@@ -1,3 +0,0 @@
-#!/usr/bin/env bash
-
-rmdir /sys/fs/cgroup/cpu/$1
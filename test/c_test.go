@@ -2,10 +2,13 @@ package test
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
 	"testing"
 
 	. "github.com/smartystreets/goconvey/convey"
@@ -34,7 +37,7 @@ func copyCSourceFile(name string, t *testing.T) {
 }
 
 // compile C source file
-func compileC(name, baseDir string, t *testing.T) string {
+func compileC(name, baseDir string, t *testing.T, extraArgs ...string) string {
 	t.Logf("Compiling file %s ...", name)
 
 	var stderr bytes.Buffer
@@ -45,6 +48,7 @@ func compileC(name, baseDir string, t *testing.T) string {
 		"-timeout=3000",
 		"-std=gnu11",
 	}
+	args = append(args, extraArgs...)
 	cmd := exec.Command("/opt/justice-sandbox/bin/clike_compiler", args...)
 	cmd.Stderr = &stderr
 	if err := cmd.Run(); err != nil {
@@ -55,7 +59,7 @@ func compileC(name, baseDir string, t *testing.T) string {
 }
 
 // run binary in our container
-func runC(baseDir, memory, timeout string, t *testing.T) (string, string) {
+func runC(baseDir, memory, timeout string, t *testing.T, extraArgs ...string) (string, string) {
 	t.Log("Running binary /Main ...")
 
 	var stdout, stderr bytes.Buffer
@@ -66,6 +70,7 @@ func runC(baseDir, memory, timeout string, t *testing.T) (string, string) {
 		"-command=./Main",
 		"-username=oj-user",
 	}
+	args = append(args, extraArgs...)
 	cmd := exec.Command("/opt/justice-sandbox/bin/clike_container", args...)
 	cmd.Stdin = strings.NewReader("10:10:23AM")
 	cmd.Stdout = &stdout
@@ -78,6 +83,47 @@ func runC(baseDir, memory, timeout string, t *testing.T) (string, string) {
 	return stdout.String(), stderr.String()
 }
 
+// run binary in our container with -result=json, the result records are read from fd 3 rather than stderr
+func runCResult(baseDir, memory, timeout string, t *testing.T, extraArgs ...string) (string, string, string) {
+	t.Log("Running binary /Main ...")
+
+	var stdout, stderr bytes.Buffer
+	args := []string{
+		"-basedir=" + baseDir,
+		"-memory=" + memory,
+		"-timeout=" + timeout,
+		"-command=./Main",
+		"-username=oj-user",
+		"-result=json",
+		"-result-fd=3",
+	}
+	args = append(args, extraArgs...)
+	results, resultWriter, err := os.Pipe()
+	if err != nil {
+		t.Errorf("Invoke `os.Pipe()` err: %v", err)
+		t.FailNow()
+	}
+	defer results.Close()
+	cmd := exec.Command("/opt/justice-sandbox/bin/clike_container", args...)
+	cmd.Stdin = strings.NewReader("10:10:23AM")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.ExtraFiles = []*os.File{resultWriter}
+	err = cmd.Start()
+	_ = resultWriter.Close()
+	if err != nil {
+		t.Errorf("Invoke `/opt/justice-sandbox/bin/clike_container %s` err: %v", strings.Join(args, " "), err)
+		t.FailNow()
+	}
+	records, _ := ioutil.ReadAll(results)
+	if err := cmd.Wait(); err != nil {
+		t.Errorf("Invoke `/opt/justice-sandbox/bin/clike_container %s` err: %v", strings.Join(args, " "), err)
+	}
+
+	t.Logf("stderr of runCResult: %s", stderr.String())
+	return stdout.String(), stderr.String(), string(records)
+}
+
 func TestC0000Fixture(t *testing.T) {
 	CProjectDir, _ = os.Getwd()
 	CBaseDir = CProjectDir + "/tmp"
@@ -111,7 +157,7 @@ func TestC0002CompilerBomb0(t *testing.T) {
 			}
 		}()
 
-		So(compileC(name, CBaseDir, t), ShouldContainSubstring, "signal: killed")
+		So(compileC(name, CBaseDir, t), ShouldStartWith, "Compile Limit Exceeded")
 	})
 }
 
@@ -126,7 +172,7 @@ func TestC0003CompilerBomb1(t *testing.T) {
 			}
 		}()
 
-		So(compileC(name, CBaseDir, t), ShouldContainSubstring, "signal: killed")
+		So(compileC(name, CBaseDir, t), ShouldStartWith, "Compile Limit Exceeded")
 	})
 }
 
@@ -141,7 +187,7 @@ func TestC0004CompilerBomb2(t *testing.T) {
 			}
 		}()
 
-		So(compileC(name, CBaseDir, t), ShouldContainSubstring, "signal: killed")
+		So(compileC(name, CBaseDir, t), ShouldStartWith, "Compile Limit Exceeded")
 	})
 }
 
@@ -156,7 +202,7 @@ func TestC0005CompilerBomb3(t *testing.T) {
 			}
 		}()
 
-		So(compileC(name, CBaseDir, t), ShouldContainSubstring, "signal: killed")
+		So(compileC(name, CBaseDir, t), ShouldStartWith, "Compile Limit Exceeded")
 	})
 }
 
@@ -226,7 +272,7 @@ func TestC0009ForkBomb0(t *testing.T) {
 
 		So(compileC(name, CBaseDir, t), ShouldBeEmpty)
 		// got `signal: killed`
-		_, stderr := runC(CBaseDir, "64000", "1000", t)
+		_, stderr := runC(CBaseDir, "64000", "1000", t, "-seccomp=none")
 		So(stderr, ShouldContainSubstring, "Time Limit Error")
 	})
 }
@@ -244,7 +290,7 @@ func TestC0010ForkBomb1(t *testing.T) {
 
 		So(compileC(name, CBaseDir, t), ShouldBeEmpty)
 		// got `signal: killed`
-		_, stderr := runC(CBaseDir, "64000", "1000", t)
+		_, stderr := runC(CBaseDir, "64000", "1000", t, "-seccomp=none")
 		So(stderr, ShouldContainSubstring, "signal: segmentation fault (core dumped)")
 	})
 }
@@ -264,7 +310,7 @@ func TestC0011GetHostByName(t *testing.T) {
 		// Main.c:(.text+0x28): warning: Using 'gethostbyname' in statically linked applications
 		// requires at runtime the shared libraries from the glibc version used for linking
 		// got `exit status 1`
-		stdout, _ := runC(CBaseDir, "64000", "1000", t)
+		stdout, _ := runC(CBaseDir, "64000", "1000", t, "-seccomp=none")
 		So(stdout, ShouldContainSubstring, "gethostbyname error")
 	})
 }
@@ -346,7 +392,7 @@ func TestC0016RunCommandLine0(t *testing.T) {
 		}()
 
 		So(compileC(name, CBaseDir, t), ShouldBeEmpty)
-		stdout, _ := runC(CBaseDir, "64000", "1000", t)
+		stdout, _ := runC(CBaseDir, "64000", "1000", t, "-seccomp=none")
 		So(stdout, ShouldEqual, "32512")
 	})
 }
@@ -363,7 +409,7 @@ func TestC0017RunCommandLine1(t *testing.T) {
 		}()
 
 		So(compileC(name, CBaseDir, t), ShouldBeEmpty)
-		stdout, _ := runC(CBaseDir, "64000", "1000", t)
+		stdout, _ := runC(CBaseDir, "64000", "1000", t, "-seccomp=none")
 		So(stdout, ShouldContainSubstring, "32512")
 	})
 }
@@ -380,7 +426,7 @@ func TestC0018Syscall0(t *testing.T) {
 		}()
 
 		So(compileC(name, CBaseDir, t), ShouldBeEmpty)
-		stdout, _ := runC(CBaseDir, "16000", "1000", t)
+		stdout, _ := runC(CBaseDir, "16000", "1000", t, "-seccomp=none")
 		So(stdout, ShouldContainSubstring, "-1")
 	})
 }
@@ -397,7 +443,960 @@ func TestC0019TCPClient(t *testing.T) {
 		}()
 
 		So(compileC(name, CBaseDir, t), ShouldBeEmpty)
-		stdout, _ := runC(CBaseDir, "16000", "1000", t)
+		stdout, _ := runC(CBaseDir, "16000", "1000", t, "-seccomp=none")
 		So(stdout, ShouldContainSubstring, "connect failed")
 	})
 }
+
+// decode the last of the json records, one per line
+func lastResult(records string, t *testing.T) map[string]interface{} {
+	lines := strings.Split(strings.TrimSpace(records), "\n")
+	result := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &result); err != nil {
+		t.Errorf("Invoke `json.Unmarshal(%s)` err: %v", lines[len(lines)-1], err)
+	}
+	return result
+}
+
+func TestC0020ResultJSON(t *testing.T) {
+	name := "ac.c"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		copyCSourceFile(name, t)
+		defer func() {
+			if err := os.RemoveAll(CBaseDir); err != nil {
+				t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", CBaseDir, err)
+				t.FailNow()
+			}
+		}()
+
+		So(compileC(name, CBaseDir, t), ShouldBeEmpty)
+		stdout, stderr, results := runCResult(CBaseDir, "64000", "1000", t)
+		So(stdout, ShouldContainSubstring, "10:10:23")
+		So(stderr, ShouldNotContainSubstring, "DEBUG")
+		So(lastResult(results, t)["verdict"], ShouldEqual, "OK")
+
+		// an unknown format is rejected before anything runs
+		var output bytes.Buffer
+		cmd := exec.Command("/opt/justice-sandbox/bin/clike_container", "-basedir="+CBaseDir, "-command=./Main",
+			"-username=oj-user", "-result=xml")
+		cmd.Stdout, cmd.Stderr = &output, &output
+		So(cmd.Run(), ShouldNotBeNil)
+		So(output.String(), ShouldContainSubstring, "unknown result format: xml")
+	})
+}
+
+func TestC0021ResultJSONTimeLimit(t *testing.T) {
+	name := "infinite_loop.c"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		copyCSourceFile(name, t)
+		defer func() {
+			if err := os.RemoveAll(CBaseDir); err != nil {
+				t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", CBaseDir, err)
+				t.FailNow()
+			}
+		}()
+
+		So(compileC(name, CBaseDir, t), ShouldBeEmpty)
+		_, _, results := runCResult(CBaseDir, "64000", "1000", t)
+		result := lastResult(results, t)
+		So(result["verdict"], ShouldEqual, "TLE")
+		So(result["killed"], ShouldEqual, "timeout")
+	})
+}
+
+func TestC0022SeccompSyscall0(t *testing.T) {
+	name := "syscall_0.c"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		copyCSourceFile(name, t)
+		defer func() {
+			if err := os.RemoveAll(CBaseDir); err != nil {
+				t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", CBaseDir, err)
+				t.FailNow()
+			}
+		}()
+
+		So(compileC(name, CBaseDir, t), ShouldBeEmpty)
+		stdout, stderr := runC(CBaseDir, "16000", "1000", t, "-seccomp=c")
+		So(stdout, ShouldBeEmpty)
+		So(stderr, ShouldContainSubstring, "Restricted Function")
+	})
+}
+
+func TestC0023SeccompRunCommandLine0(t *testing.T) {
+	name := "run_command_line_0.c"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		copyCSourceFile(name, t)
+		defer func() {
+			if err := os.RemoveAll(CBaseDir); err != nil {
+				t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", CBaseDir, err)
+				t.FailNow()
+			}
+		}()
+
+		So(compileC(name, CBaseDir, t), ShouldBeEmpty)
+		_, _, results := runCResult(CBaseDir, "64000", "1000", t, "-seccomp=c")
+		So(lastResult(results, t)["verdict"], ShouldEqual, "RF")
+	})
+}
+
+func TestC0024CPUTimeLimit(t *testing.T) {
+	name := "infinite_loop.c"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		copyCSourceFile(name, t)
+		defer func() {
+			if err := os.RemoveAll(CBaseDir); err != nil {
+				t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", CBaseDir, err)
+				t.FailNow()
+			}
+		}()
+
+		So(compileC(name, CBaseDir, t), ShouldBeEmpty)
+		_, _, results := runCResult(CBaseDir, "64000", "5000", t, "-cpu-time=200", "-cpu-quota=-1")
+		result := lastResult(results, t)
+		So(result["verdict"], ShouldEqual, "TLE")
+		So(result["killed"], ShouldEqual, "cpu time")
+		So(result["cpuTime"], ShouldBeGreaterThanOrEqualTo, 200)
+		So(result["wallTime"], ShouldBeLessThan, 5000)
+		// justiceInit survives the kill and reports the command
+		So(result["signal"], ShouldEqual, 9)
+		So(result["error"], ShouldBeNil)
+		So(result["memory"], ShouldBeGreaterThan, 0)
+	})
+}
+
+func TestC0025MemoryPeak(t *testing.T) {
+	name := "memory_allocation.c"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		copyCSourceFile(name, t)
+		defer func() {
+			if err := os.RemoveAll(CBaseDir); err != nil {
+				t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", CBaseDir, err)
+				t.FailNow()
+			}
+		}()
+
+		So(compileC(name, CBaseDir, t), ShouldBeEmpty)
+		_, _, results := runCResult(CBaseDir, "16000", "1000", t)
+		result := lastResult(results, t)
+		So(result["verdict"], ShouldEqual, "MLE")
+		So(result["killed"], ShouldEqual, "oom")
+		So(result["memoryLimit"], ShouldEqual, 16000)
+		// when the kernel charges the last pages before the OOM kill varies, the peak is close to the limit
+		So(result["memory"], ShouldBeGreaterThanOrEqualTo, 0.9*16000)
+	})
+}
+
+func TestC0026OutputLimit(t *testing.T) {
+	name := "output_flood.c"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		copyCSourceFile(name, t)
+		defer func() {
+			if err := os.RemoveAll(CBaseDir); err != nil {
+				t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", CBaseDir, err)
+				t.FailNow()
+			}
+		}()
+
+		So(compileC(name, CBaseDir, t), ShouldBeEmpty)
+		stdout, stderr := runC(CBaseDir, "16000", "1000", t, "-stdout-limit=64")
+		So(len(stdout), ShouldEqual, 64*1024)
+		So(stderr, ShouldContainSubstring, "Output Limit Error")
+
+		// the output kept for -check is limited even without -stdout-limit
+		stdout, stderr = runC(CBaseDir, "16000", "10000", t, "-check=exact", "-expected=/dev/null")
+		So(len(stdout), ShouldEqual, 64*1024*1024)
+		So(stderr, ShouldContainSubstring, "Output Limit Error")
+	})
+}
+
+// write the input files of a batch to a fresh dir next to CBaseDir
+func writeBatchInputs(dir string, inputs map[string]string, t *testing.T) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		t.Errorf("Invoke mkdir(%s) err: %v", dir, err.Error())
+	}
+	for name, input := range inputs {
+		if err := ioutil.WriteFile(dir+"/"+name, []byte(input), 0644); err != nil {
+			t.Errorf("Invoke `ioutil.WriteFile(%s)` err: %v", name, err)
+		}
+	}
+}
+
+func TestC0027Batch(t *testing.T) {
+	name := "ac.c"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		copyCSourceFile(name, t)
+		batchDir := CProjectDir + "/tmp_batch"
+		defer func() {
+			for _, dir := range []string{CBaseDir, batchDir} {
+				if err := os.RemoveAll(dir); err != nil {
+					t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", dir, err)
+					t.FailNow()
+				}
+			}
+		}()
+		writeBatchInputs(batchDir, map[string]string{"1.in": "10:10:23AM", "2.in": "07:05:45PM"}, t)
+
+		So(compileC(name, CBaseDir, t), ShouldBeEmpty)
+		_, _, results := runCResult(CBaseDir, "64000", "1000", t, "-batch="+batchDir, "-batch-output="+batchDir)
+		lines := strings.Split(strings.TrimSpace(results), "\n")
+		So(lines, ShouldHaveLength, 2)
+		for i, expected := range []string{"10:10:23", "19:05:45"} {
+			result := lastResult(lines[i], t)
+			So(result["case"], ShouldEqual, fmt.Sprint(i+1))
+			So(result["verdict"], ShouldEqual, "OK")
+			So(result["memory"], ShouldBeGreaterThan, 0)
+			output, _ := ioutil.ReadFile(fmt.Sprintf("%s/%d.out", batchDir, i+1))
+			So(string(output), ShouldEqual, expected)
+		}
+	})
+}
+
+func TestC0028BatchStopOnFailure(t *testing.T) {
+	name := "infinite_loop.c"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		copyCSourceFile(name, t)
+		batchDir := CProjectDir + "/tmp_batch"
+		defer func() {
+			for _, dir := range []string{CBaseDir, batchDir} {
+				if err := os.RemoveAll(dir); err != nil {
+					t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", dir, err)
+					t.FailNow()
+				}
+			}
+		}()
+		writeBatchInputs(batchDir, map[string]string{
+			"manifest.json": `[{"name": "b", "input": "b.in", "output": "b.out"}, {"name": "a", "input": "a.in", "output": "a.out"}]`,
+			"a.in":          "",
+			"b.in":          "",
+		}, t)
+
+		So(compileC(name, CBaseDir, t), ShouldBeEmpty)
+		_, stderr := runC(CBaseDir, "64000", "500", t, "-batch="+batchDir+"/manifest.json", "-stop-on-failure")
+		So(stderr, ShouldContainSubstring, "INFO: case:b\nTime Limit Error")
+		So(stderr, ShouldNotContainSubstring, "INFO: case:a")
+	})
+}
+
+func TestC0029CheckAccepted(t *testing.T) {
+	name := "ac.c"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		copyCSourceFile(name, t)
+		batchDir := CProjectDir + "/tmp_batch"
+		defer func() {
+			for _, dir := range []string{CBaseDir, batchDir} {
+				if err := os.RemoveAll(dir); err != nil {
+					t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", dir, err)
+					t.FailNow()
+				}
+			}
+		}()
+		writeBatchInputs(batchDir, map[string]string{"expected": "10:10:23  \n\n"}, t)
+
+		So(compileC(name, CBaseDir, t), ShouldBeEmpty)
+		_, stderr := runC(CBaseDir, "64000", "1000", t, "-check=lines", "-expected="+batchDir+"/expected")
+		So(stderr, ShouldContainSubstring, "Accepted")
+	})
+}
+
+func TestC0030CheckWrongAnswer(t *testing.T) {
+	name := "ac.c"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		copyCSourceFile(name, t)
+		batchDir := CProjectDir + "/tmp_batch"
+		defer func() {
+			for _, dir := range []string{CBaseDir, batchDir} {
+				if err := os.RemoveAll(dir); err != nil {
+					t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", dir, err)
+					t.FailNow()
+				}
+			}
+		}()
+		writeBatchInputs(batchDir, map[string]string{"1.in": "10:10:23AM", "1.ans": "10:10:23\n", "2.in": "10:10:23PM", "2.ans": "10:10:23"}, t)
+
+		So(compileC(name, CBaseDir, t), ShouldBeEmpty)
+		_, _, results := runCResult(CBaseDir, "64000", "1000", t,
+			"-batch="+batchDir, "-batch-output="+batchDir, "-check=tokens")
+		lines := strings.Split(strings.TrimSpace(results), "\n")
+		So(lines, ShouldHaveLength, 2)
+		So(lastResult(lines[0], t)["verdict"], ShouldEqual, "AC")
+		result := lastResult(lines[1], t)
+		So(result["verdict"], ShouldEqual, "WA")
+		So(result["difference"], ShouldResemble, map[string]interface{}{
+			"line": 1.0, "column": 1.0, "expected": "10:10:23", "actual": "22:10:23",
+		})
+	})
+}
+
+func TestC0031CheckFloat(t *testing.T) {
+	name := "float_output.c"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		copyCSourceFile(name, t)
+		batchDir := CProjectDir + "/tmp_batch"
+		defer func() {
+			for _, dir := range []string{CBaseDir, batchDir} {
+				if err := os.RemoveAll(dir); err != nil {
+					t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", dir, err)
+					t.FailNow()
+				}
+			}
+		}()
+		writeBatchInputs(batchDir, map[string]string{"expected": "0.333333 0.666667"}, t)
+
+		So(compileC(name, CBaseDir, t), ShouldBeEmpty)
+		_, _, results := runCResult(CBaseDir, "64000", "1000", t, "-check=float", "-expected="+batchDir+"/expected")
+		So(lastResult(results, t)["verdict"], ShouldEqual, "AC")
+		_, _, results = runCResult(CBaseDir, "64000", "1000", t, "-check=float", "-abs-eps=0", "-rel-eps=1e-9", "-expected="+batchDir+"/expected")
+		So(lastResult(results, t)["verdict"], ShouldEqual, "WA")
+	})
+}
+
+// compile the checker or interactor `*.c` as `Main` in dir
+func compileCProgram(name, dir string, t *testing.T) string {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		t.Errorf("Invoke mkdir(%s) err: %v", dir, err.Error())
+	}
+	if err := exec.Command("cp", CProjectDir+"/resources/c/"+name, dir+"/Main.c").Run(); err != nil {
+		t.Errorf("Invoke `cp %s` err: %v", name, err)
+	}
+	return compileC(name, dir, t)
+}
+
+func TestC0032SpecialJudge(t *testing.T) {
+	name := "ac.c"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		copyCSourceFile(name, t)
+		batchDir := CProjectDir + "/tmp_batch"
+		checkerDir := CProjectDir + "/tmp_checker"
+		defer func() {
+			for _, dir := range []string{CBaseDir, batchDir, checkerDir} {
+				if err := os.RemoveAll(dir); err != nil {
+					t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", dir, err)
+					t.FailNow()
+				}
+			}
+		}()
+		writeBatchInputs(batchDir, map[string]string{"1.in": "10:10:23AM", "1.ans": "10:10:23", "2.in": "10:10:23PM", "2.ans": "10:10:23"}, t)
+
+		So(compileC(name, CBaseDir, t), ShouldBeEmpty)
+		So(compileCProgram("checker.c", checkerDir, t), ShouldBeEmpty)
+		_, _, results := runCResult(CBaseDir, "64000", "1000", t,
+			"-batch="+batchDir, "-batch-output="+batchDir, "-checker="+checkerDir+"/Main", "-seccomp=c")
+		lines := strings.Split(strings.TrimSpace(results), "\n")
+		So(lines, ShouldHaveLength, 2)
+		result := lastResult(lines[0], t)
+		So(result["verdict"], ShouldEqual, "AC")
+		So(result["message"], ShouldEqual, "ok 10:10:23")
+		result = lastResult(lines[1], t)
+		So(result["verdict"], ShouldEqual, "WA")
+		So(result["message"], ShouldEqual, "expected 10:10:23, found 22:10:23")
+	})
+}
+
+func TestC0033Interactor(t *testing.T) {
+	name := "guess.c"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		copyCSourceFile(name, t)
+		batchDir := CProjectDir + "/tmp_batch"
+		interactorDir := CProjectDir + "/tmp_interactor"
+		defer func() {
+			for _, dir := range []string{CBaseDir, batchDir, interactorDir} {
+				if err := os.RemoveAll(dir); err != nil {
+					t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", dir, err)
+					t.FailNow()
+				}
+			}
+		}()
+		writeBatchInputs(batchDir, map[string]string{"1.in": "424242", "2.in": "1"}, t)
+
+		So(compileC(name, CBaseDir, t), ShouldBeEmpty)
+		So(compileCProgram("interactor.c", interactorDir, t), ShouldBeEmpty)
+		_, _, results := runCResult(CBaseDir, "64000", "1000", t,
+			"-batch="+batchDir, "-batch-output="+batchDir, "-interactor="+interactorDir+"/Main", "-seccomp=c")
+		lines := strings.Split(strings.TrimSpace(results), "\n")
+		So(lines, ShouldHaveLength, 2)
+		for _, line := range lines {
+			result := lastResult(line, t)
+			So(result["verdict"], ShouldEqual, "AC")
+			So(result["message"], ShouldStartWith, "ok")
+			So(result["interactor"], ShouldNotBeNil)
+		}
+	})
+}
+
+func TestC0034InteractorIdle(t *testing.T) {
+	name := "ac.c"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		copyCSourceFile(name, t)
+		interactorDir := CProjectDir + "/tmp_interactor"
+		defer func() {
+			for _, dir := range []string{CBaseDir, interactorDir} {
+				if err := os.RemoveAll(dir); err != nil {
+					t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", dir, err)
+					t.FailNow()
+				}
+			}
+		}()
+
+		So(compileC(name, CBaseDir, t), ShouldBeEmpty)
+		So(compileCProgram("interactor.c", interactorDir, t), ShouldBeEmpty)
+		// both read stdin first
+		_, _, results := runCResult(CBaseDir, "64000", "5000", t, "-interactor="+interactorDir+"/Main", "-idle-limit=500")
+		result := lastResult(results, t)
+		So(result["verdict"], ShouldEqual, "ILE")
+		So(result["wallTime"], ShouldBeLessThan, 5000)
+	})
+}
+
+func TestC0035IncludeHostFile(t *testing.T) {
+	name := "ac.c"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		if err := os.MkdirAll(CBaseDir, os.ModePerm); err != nil {
+			t.Errorf("Invoke mkdir(%s) err: %v", CBaseDir, err.Error())
+		}
+		defer func() {
+			if err := os.RemoveAll(CBaseDir); err != nil {
+				t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", CBaseDir, err)
+				t.FailNow()
+			}
+		}()
+		// the file exists on the host, but not in the rootfs of the compiler
+		source := fmt.Sprintf("#include <%s/resources/c/%s>\n", CProjectDir, name)
+		if err := ioutil.WriteFile(CBaseDir+"/Main.c", []byte(source), 0644); err != nil {
+			t.Errorf("Invoke `ioutil.WriteFile(Main.c)` err: %v", err)
+		}
+
+		So(compileC(name, CBaseDir, t), ShouldContainSubstring, "resources/c/ac.c: No such file or directory")
+	})
+}
+
+func TestC0036LanguageProfiles(t *testing.T) {
+	name := "ac.c"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		copyCSourceFile(name, t)
+		defer func() {
+			if err := os.RemoveAll(CBaseDir); err != nil {
+				t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", CBaseDir, err)
+				t.FailNow()
+			}
+		}()
+		profiles := CBaseDir + "/languages.yaml"
+		if err := ioutil.WriteFile(profiles, []byte(`
+- name: c-o2
+  compiler: /usr/bin/gcc
+  args: ["-O2", "-static", "-o", "{output}", "{source}"]
+  source: Solution.c
+  output: Main
+`), 0644); err != nil {
+			t.Errorf("Invoke `ioutil.WriteFile(%s)` err: %v", profiles, err)
+		}
+		if err := os.Rename(CBaseDir+"/Main.c", CBaseDir+"/Solution.c"); err != nil {
+			t.Errorf("Invoke `os.Rename(Main.c)` err: %v", err)
+		}
+
+		So(compileC(name, CBaseDir, t, "-lang=c-o2", "-filename=", "-std="), ShouldContainSubstring, "unknown language")
+		So(compileC(name, CBaseDir, t, "-lang=c-o2", "-lang-profiles="+profiles, "-filename=", "-std="), ShouldBeEmpty)
+		stdout, _ := runC(CBaseDir, "64000", "1000", t)
+		So(stdout, ShouldEqual, "10:10:23")
+	})
+}
+
+func TestC0037ArgsEnv(t *testing.T) {
+	name := "args_env.c"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		copyCSourceFile(name, t)
+		defer func() {
+			if err := os.RemoveAll(CBaseDir); err != nil {
+				t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", CBaseDir, err)
+				t.FailNow()
+			}
+		}()
+
+		// LANG and PATH are passed through from the host, TZ of the host is overridden by -env
+		_ = os.Setenv("LANG", "C.UTF-8")
+		defer os.Unsetenv("LANG")
+		So(compileC(name, CBaseDir, t), ShouldBeEmpty)
+		stdout, _ := runC(CBaseDir, "64000", "1000", t,
+			"-arg=hello world", "-arg=", "-arg=a \"b\"\nc", "-env=TZ=UTC", "-env-allow=LANG,TZ,PATH")
+		So(stdout, ShouldEqual, "[hello world][][a \"b\"\nc]\nTZ=UTC LANG=C.UTF-8 PATH="+os.Getenv("PATH")+"\n")
+	})
+}
+
+// compile the source with -format=json and decode the result
+func compileCJSON(baseDir string, t *testing.T, extraArgs ...string) map[string]interface{} {
+	var stdout bytes.Buffer
+	args := append([]string{"-basedir=" + baseDir, "-timeout=3000", "-format=json"}, extraArgs...)
+	cmd := exec.Command("/opt/justice-sandbox/bin/clike_compiler", args...)
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		t.Errorf("Invoke `/opt/justice-sandbox/bin/clike_compiler %s` err: %v", strings.Join(args, " "), err)
+	}
+	result := make(map[string]interface{})
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		t.Errorf("Invoke `json.Unmarshal(%s)` err: %v", stdout.String(), err)
+	}
+	return result
+}
+
+func TestC0038Diagnostics(t *testing.T) {
+	name := "compile_error.c"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		copyCSourceFile(name, t)
+		profiles := CProjectDir + "/profiles.yaml"
+		defer func() {
+			for _, name := range []string{CBaseDir, profiles} {
+				if err := os.RemoveAll(name); err != nil {
+					t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", name, err)
+					t.FailNow()
+				}
+			}
+		}()
+		if err := ioutil.WriteFile(profiles, []byte(`
+- name: c-text
+  compiler: /usr/bin/gcc
+  args: ["-static", "-o", "{output}", "{source}"]
+  source: Main.c
+  output: Main
+`), 0644); err != nil {
+			t.Errorf("Invoke `ioutil.WriteFile(%s)` err: %v", profiles, err)
+		}
+
+		// the json format of gcc and the classic text format
+		for _, args := range [][]string{{"-lang=c"}, {"-lang=c-text", "-lang-profiles=" + profiles}} {
+			result := compileCJSON(CBaseDir, t, args...)
+			So(result["ok"], ShouldBeFalse)
+			So(result["exitCode"], ShouldEqual, 1)
+			So(result["timeout"], ShouldBeFalse)
+			So(result["memory"], ShouldBeGreaterThan, 0)
+			diagnostics := result["diagnostics"].([]interface{})
+			So(len(diagnostics), ShouldBeGreaterThanOrEqualTo, 2)
+			So(diagnostics[0], ShouldResemble, map[string]interface{}{
+				"file": "Main.c", "line": 4.0, "column": 13.0, "severity": "error", "message": "expected expression before ';' token",
+			})
+			So(diagnostics[1].(map[string]interface{})["line"], ShouldEqual, 5)
+			So(diagnostics[1].(map[string]interface{})["message"], ShouldStartWith, "'y' undeclared")
+			So(result["output"], ShouldContainSubstring, "Main.c:4:13: error: expected expression before ';' token")
+			So(result["output"], ShouldNotStartWith, "[")
+		}
+
+		if err := exec.Command("cp", CProjectDir+"/resources/c/ac.c", CBaseDir+"/Main.c").Run(); err != nil {
+			t.Errorf("Invoke `cp ac.c` err: %v", err)
+		}
+		result := compileCJSON(CBaseDir, t)
+		So(result["ok"], ShouldBeTrue)
+		So(result["diagnostics"], ShouldBeEmpty)
+	})
+}
+
+func TestC0039CompileCache(t *testing.T) {
+	name := "ac.c"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		copyCSourceFile(name, t)
+		cacheDir := CProjectDir + "/tmp_cache"
+		defer func() {
+			for _, dir := range []string{CBaseDir, cacheDir} {
+				if err := os.RemoveAll(dir); err != nil {
+					t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", dir, err)
+					t.FailNow()
+				}
+			}
+		}()
+
+		result := compileCJSON(CBaseDir, t, "-cache-dir="+cacheDir)
+		So(result["ok"], ShouldBeTrue)
+		So(result["cached"], ShouldBeFalse)
+		// the binary is copied from the cache
+		_ = os.Remove(CBaseDir + "/Main")
+		result = compileCJSON(CBaseDir, t, "-cache-dir="+cacheDir)
+		So(result["ok"], ShouldBeTrue)
+		So(result["cached"], ShouldBeTrue)
+		stdout, _ := runC(CBaseDir, "64000", "1000", t)
+		So(stdout, ShouldEqual, "10:10:23")
+		So(compileCJSON(CBaseDir, t, "-cache-dir="+cacheDir, "-no-cache")["cached"], ShouldBeFalse)
+		So(compileCJSON(CBaseDir, t, "-cache-dir="+cacheDir, "-std=gnu99")["cached"], ShouldBeFalse)
+
+		// a compile error is cached as well
+		if err := exec.Command("cp", CProjectDir+"/resources/c/compile_error.c", CBaseDir+"/Main.c").Run(); err != nil {
+			t.Errorf("Invoke `cp compile_error.c` err: %v", err)
+		}
+		So(compileC(name, CBaseDir, t, "-cache-dir="+cacheDir), ShouldContainSubstring, "expected expression")
+		result = compileCJSON(CBaseDir, t, "-cache-dir="+cacheDir)
+		So(result["ok"], ShouldBeFalse)
+		So(result["cached"], ShouldBeTrue)
+		So(result["diagnostics"], ShouldNotBeEmpty)
+
+		// two static binaries take more than 1MB, those used least recently are evicted
+		entries, _ := ioutil.ReadDir(cacheDir)
+		So(entries, ShouldHaveLength, 3)
+		if err := exec.Command("cp", CProjectDir+"/resources/c/args_env.c", CBaseDir+"/Main.c").Run(); err != nil {
+			t.Errorf("Invoke `cp args_env.c` err: %v", err)
+		}
+		So(compileCJSON(CBaseDir, t, "-cache-dir="+cacheDir, "-cache-size=1")["ok"], ShouldBeTrue)
+		entries, _ = ioutil.ReadDir(cacheDir)
+		So(entries, ShouldHaveLength, 2)
+		So(compileCJSON(CBaseDir, t, "-cache-dir="+cacheDir)["cached"], ShouldBeTrue)
+		if err := exec.Command("cp", CProjectDir+"/resources/c/compile_error.c", CBaseDir+"/Main.c").Run(); err != nil {
+			t.Errorf("Invoke `cp compile_error.c` err: %v", err)
+		}
+		So(compileCJSON(CBaseDir, t, "-cache-dir="+cacheDir)["cached"], ShouldBeTrue)
+
+		// the limits of the outcome are part of the key as well
+		So(compileCJSON(CBaseDir, t, "-cache-dir="+cacheDir, "-file-size=32768")["cached"], ShouldBeFalse)
+		So(compileCJSON(CBaseDir, t, "-cache-dir="+cacheDir, "-output-limit=128")["cached"], ShouldBeFalse)
+	})
+}
+
+func TestC0040MultiFile(t *testing.T) {
+	name := "multi_file"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		if err := os.MkdirAll(CBaseDir, os.ModePerm); err != nil {
+			t.Errorf("Invoke mkdir(%s) err: %v", CBaseDir, err.Error())
+		}
+		defer func() {
+			if err := os.RemoveAll(CBaseDir); err != nil {
+				t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", CBaseDir, err)
+				t.FailNow()
+			}
+		}()
+		copySource := func(name, filename string) {
+			if err := exec.Command("cp", CProjectDir+"/resources/c/multi_file/"+name, CBaseDir+"/"+filename).Run(); err != nil {
+				t.Errorf("Invoke `cp %s` err: %v", name, err)
+			}
+		}
+		for _, name := range []string{"Main.c", "util.c", "util.h"} {
+			copySource(name, name)
+		}
+		// the files of the problem are read as the user of the compiler, which cannot enter the project dir
+		problems, err := ioutil.TempDir("", "problems-")
+		if err != nil {
+			t.Errorf("Invoke `ioutil.TempDir` err: %v", err)
+			t.FailNow()
+		}
+		defer os.RemoveAll(problems)
+		_ = os.Chmod(problems, 0755)
+		if err := exec.Command("cp", "-r", CProjectDir+"/resources/c/grader", problems).Run(); err != nil {
+			t.Errorf("Invoke `cp grader` err: %v", err)
+		}
+		_ = ioutil.WriteFile(problems+"/secret.c", []byte("int secret = 42;\n"), 0600)
+
+		// the submission of two files is linked with the grader, whose header is found in its include dir
+		So(compileC(name, CBaseDir, t, "-sources=util.c,util.h", "-problem-dir="+problems, "-include-dirs=grader", "-link="+problems+"/grader/grader.c"), ShouldBeEmpty)
+		stdout, _ := runC(CBaseDir, "64000", "1000", t)
+		So(stdout, ShouldEqual, "43\n")
+		_, err = os.Stat(CBaseDir + "/grader.c")
+		So(os.IsNotExist(err), ShouldBeTrue)
+		// the files of the problem are neither outside of the problem dir nor unreadable to the user
+		So(compileC(name, CBaseDir, t, "-sources=util.c,util.h", "-include-dirs=grader", "-link=grader/grader.c"), ShouldContainSubstring, "no directory to resolve")
+		So(compileC(name, CBaseDir, t, "-sources=util.c,util.h", "-problem-dir="+problems, "-link=/etc/passwd"), ShouldContainSubstring, "must be below")
+		So(compileC(name, CBaseDir, t, "-sources=util.c,util.h", "-problem-dir="+problems, "-include-dirs=../"), ShouldContainSubstring, "must be below")
+		So(compileC(name, CBaseDir, t, "-sources=util.c,util.h", "-problem-dir="+problems, "-link=secret.c"), ShouldContainSubstring, "permission denied")
+		So(compileC(name, CBaseDir, t, "-sources=../util.c"), ShouldContainSubstring, "invalid source")
+		So(compileC(name, CBaseDir, t, "-sources=Main.c"), ShouldContainSubstring, "duplicate source")
+		// the submission is read as root, neither its name nor a symlink may lead out of the basedir
+		So(compileC(name, CBaseDir, t, "-filename=../../../../etc/shadow"), ShouldContainSubstring, "invalid source")
+		So(os.Symlink("/etc/shadow", CBaseDir+"/Shadow.c"), ShouldBeNil)
+		So(compileC(name, CBaseDir, t, "-filename=Shadow.c"), ShouldContainSubstring, "symbolic links")
+		So(compileC(name, CBaseDir, t, "-sources=util.c,util.h,Shadow.c"), ShouldContainSubstring, "symbolic links")
+
+		// forbidden headers are shadowed, includes escaping the include path are rejected
+		copySource("forbidden_math.c", "Main.c")
+		So(compileC(name, CBaseDir, t, "-forbidden-headers=math.h"), ShouldContainSubstring, "header <math.h> is forbidden")
+		copySource("include_by_path.c", "Main.c")
+		result := compileCJSON(CBaseDir, t, "-forbidden-headers=bits/stdc++.h")
+		So(result["ok"], ShouldBeFalse)
+		diagnostics := result["diagnostics"].([]interface{})
+		So(diagnostics, ShouldHaveLength, 5)
+		// the last two start with the digraph %: and the trigraph ??= of #
+		for i, line := range []float64{2, 3, 6, 11, 12} {
+			So(diagnostics[i].(map[string]interface{})["line"], ShouldEqual, line)
+		}
+		So(diagnostics[2].(map[string]interface{})["message"], ShouldEqual, "computed #include is forbidden")
+
+		// another relative path of a forbidden header escapes its shadow but not the headers listed by the compiler
+		copySource("include_alias.c", "Main.c")
+		So(compileC(name, CBaseDir, t, "-forbidden-headers=sys/time.h"), ShouldContainSubstring, "header <sys/time.h> is forbidden")
+		So(compileC(name, CBaseDir, t, "-forbidden-headers=bits/stdc++.h"), ShouldBeEmpty)
+	})
+}
+
+func TestC0041CompilerFlags(t *testing.T) {
+	name := "flags.c"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		copyCSourceFile(name, t)
+		profiles := CProjectDir + "/profiles.yaml"
+		defer func() {
+			for _, name := range []string{CBaseDir, profiles} {
+				if err := os.RemoveAll(name); err != nil {
+					t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", name, err)
+					t.FailNow()
+				}
+			}
+		}()
+		if err := ioutil.WriteFile(profiles, []byte(`
+- name: c-native
+  compiler: /usr/bin/gcc
+  args: ["{sources}", "-static", "-o", "{output}"]
+  source: Main.c
+  output: Main
+  allowedFlags: ["-march=*", "-l*", "-B*"]
+`), 0644); err != nil {
+			t.Errorf("Invoke `ioutil.WriteFile(%s)` err: %v", profiles, err)
+		}
+
+		So(compileC(name, CBaseDir, t, "-flag=-DONLINE_JUDGE"), ShouldContainSubstring, "undefined reference to `sqrt'")
+		So(compileC(name, CBaseDir, t, "-flag=-DONLINE_JUDGE", "-flag=-O2", "-flag=-lm", "-flag=-Wall"), ShouldBeEmpty)
+		stdout, _ := runC(CBaseDir, "64000", "1000", t)
+		So(stdout, ShouldEqual, "1.414\n")
+
+		// flags running other programs than the compiler are rejected
+		for _, flag := range []string{"-fplugin=/code/plugin.so", "-wrapper=/bin/sh", "@/code/flags", "-B/code", "-Wl,-plugin=/code/plugin.so", "-specs=/code/specs"} {
+			So(compileC(name, CBaseDir, t, "-flag="+flag), ShouldContainSubstring, "is forbidden")
+		}
+		So(compileC(name, CBaseDir, t, "-flag=-include", "-flag=/etc/passwd"), ShouldContainSubstring, "is not allowed by language c")
+		So(compileC(name, CBaseDir, t, "-flag=-march=native"), ShouldContainSubstring, "is not allowed by language c")
+
+		// the allowlist of a profile, which cannot allow forbidden flags
+		args := []string{"-lang=c-native", "-lang-profiles=" + profiles, "-std="}
+		So(compileC(name, CBaseDir, t, append(args, "-flag=-march=native", "-flag=-lm")...), ShouldBeEmpty)
+		So(compileC(name, CBaseDir, t, append(args, "-flag=-B/code")...), ShouldContainSubstring, "is forbidden")
+	})
+}
+
+func TestC0042CompileLimits(t *testing.T) {
+	name := "compile_output_flood.c"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		copyCSourceFile(name, t)
+		defer func() {
+			if err := os.RemoveAll(CBaseDir); err != nil {
+				t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", CBaseDir, err)
+				t.FailNow()
+			}
+		}()
+
+		So(compileC(name, CBaseDir, t, "-output-limit=1"), ShouldStartWith, "Compile Limit Exceeded: output limit of 1KB")
+		result := compileCJSON(CBaseDir, t, "-output-limit=1")
+		So(result["verdict"], ShouldEqual, "CLE")
+		So(result["limit"], ShouldEqual, "output")
+		So(len(result["output"].(string)), ShouldBeLessThanOrEqualTo, 2*1024)
+
+		// the static binary is larger than 512KB
+		if err := exec.Command("cp", CProjectDir+"/resources/c/ac.c", CBaseDir+"/Main.c").Run(); err != nil {
+			t.Errorf("Invoke `cp ac.c` err: %v", err)
+		}
+		So(compileC(name, CBaseDir, t, "-file-size=512"), ShouldStartWith, "Compile Limit Exceeded: file size limit of 512KB")
+		result = compileCJSON(CBaseDir, t, "-file-size=512")
+		So(result["verdict"], ShouldEqual, "CLE")
+		So(result["limit"], ShouldEqual, "fileSize")
+
+		// the intermediate files of -save-temps are not copied back
+		So(compileC(name, CBaseDir, t), ShouldBeEmpty)
+		files, _ := ioutil.ReadDir(CBaseDir)
+		var names []string
+		for _, info := range files {
+			names = append(names, info.Name())
+		}
+		So(names, ShouldResemble, []string{"Main", "Main.c"})
+		So(compileCJSON(CBaseDir, t)["verdict"], ShouldEqual, "OK")
+
+		// the diagnostics of the submission do not pass for the file size limit
+		if err := exec.Command("cp", CProjectDir+"/resources/c/fake_file_size.c", CBaseDir+"/Main.c").Run(); err != nil {
+			t.Errorf("Invoke `cp fake_file_size.c` err: %v", err)
+		}
+		So(compileCJSON(CBaseDir, t, "-file-size=512")["verdict"], ShouldEqual, "CE")
+	})
+}
+
+func TestC0043ConcurrentTimeLimits(t *testing.T) {
+	name := "infinite_loop.c"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		copyCSourceFile(name, t)
+		defer func() {
+			if err := os.RemoveAll(CBaseDir); err != nil {
+				t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", CBaseDir, err)
+				t.FailNow()
+			}
+		}()
+
+		So(compileC(name, CBaseDir, t), ShouldBeEmpty)
+		// no TLE is lost under load, the timer and the exit of the command race in every run
+		const runs = 8
+		results := make([]string, runs)
+		var wg sync.WaitGroup
+		for i := 0; i < runs; i++ {
+			// the base dir becomes the root of the run, so every run needs its own
+			dir := fmt.Sprintf("%s/run_%d", CBaseDir, i)
+			if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+				t.Errorf("Invoke `os.MkdirAll(%s)` err: %v", dir, err)
+			}
+			if err := exec.Command("cp", CBaseDir+"/Main", dir).Run(); err != nil {
+				t.Errorf("Invoke `cp Main %s` err: %v", dir, err)
+			}
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, _, results[i] = runCResult(dir, "64000", "500", t, "-cpu-quota=-1")
+			}(i)
+		}
+		wg.Wait()
+		for _, r := range results {
+			result := lastResult(r, t)
+			So(result["verdict"], ShouldEqual, "TLE")
+			So(result["killed"], ShouldEqual, "timeout")
+			So(result["oomKilled"], ShouldBeFalse)
+		}
+	})
+}
+
+func TestC0044BatchPool(t *testing.T) {
+	name := "ac.c"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		copyCSourceFile(name, t)
+		batchDir := CProjectDir + "/tmp_batch"
+		defer func() {
+			for _, dir := range []string{CBaseDir, batchDir} {
+				if err := os.RemoveAll(dir); err != nil {
+					t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", dir, err)
+					t.FailNow()
+				}
+			}
+		}()
+		writeBatchInputs(batchDir, map[string]string{"1.in": "10:10:23AM", "2.in": "07:05:45PM", "3.in": "12:00:00AM"}, t)
+
+		So(compileC(name, CBaseDir, t), ShouldBeEmpty)
+		_, _, results := runCResult(CBaseDir, "64000", "1000", t, "-batch="+batchDir, "-batch-output="+batchDir)
+		lines := strings.Split(strings.TrimSpace(results), "\n")
+		So(lines, ShouldHaveLength, 3)
+		for i, expected := range []string{"10:10:23", "19:05:45", "00:00:00"} {
+			result := lastResult(lines[i], t)
+			So(result["case"], ShouldEqual, fmt.Sprint(i+1))
+			So(result["verdict"], ShouldEqual, "OK")
+			So(result["memory"], ShouldBeGreaterThan, 0)
+			output, _ := ioutil.ReadFile(fmt.Sprintf("%s/%d.out", batchDir, i+1))
+			So(string(output), ShouldEqual, expected)
+		}
+	})
+}
+
+func TestC0045FakeResult(t *testing.T) {
+	name := "fake_result.c"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		copyCSourceFile(name, t)
+		defer func() {
+			if err := os.RemoveAll(CBaseDir); err != nil {
+				t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", CBaseDir, err)
+				t.FailNow()
+			}
+		}()
+
+		So(compileC(name, CBaseDir, t), ShouldBeEmpty)
+		_, stderr, results := runCResult(CBaseDir, "64000", "1000", t)
+		So(stderr, ShouldContainSubstring, `{"verdict":"OK"`)
+		So(strings.Split(strings.TrimSpace(results), "\n"), ShouldHaveLength, 1)
+		So(lastResult(results, t)["verdict"], ShouldEqual, "RE")
+
+		// the record is never mixed into stderr of the command
+		_, stderr = runC(CBaseDir, "64000", "1000", t, "-result=json")
+		So(stderr, ShouldContainSubstring, "-result=json needs -result-file or -result-fd")
+	})
+}
+
+func TestC0046DefaultSeccomp(t *testing.T) {
+	name := "run_command_line_0.c"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		copyCSourceFile(name, t)
+		defer func() {
+			if err := os.RemoveAll(CBaseDir); err != nil {
+				t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", CBaseDir, err)
+				t.FailNow()
+			}
+		}()
+
+		So(compileC(name, CBaseDir, t), ShouldBeEmpty)
+		// the profile of c filters compiled submissions unless -seccomp=none
+		_, _, results := runCResult(CBaseDir, "64000", "1000", t)
+		So(lastResult(results, t)["verdict"], ShouldEqual, "RF")
+	})
+}
+
+func TestC0047PoolIPC(t *testing.T) {
+	name := "shm_leftover.c"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		copyCSourceFile(name, t)
+		batchDir := CProjectDir + "/tmp_batch"
+		defer func() {
+			for _, dir := range []string{CBaseDir, batchDir} {
+				if err := os.RemoveAll(dir); err != nil {
+					t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", dir, err)
+					t.FailNow()
+				}
+			}
+		}()
+		writeBatchInputs(batchDir, map[string]string{"1.in": "", "2.in": ""}, t)
+
+		So(compileC(name, CBaseDir, t), ShouldBeEmpty)
+		// the second case runs in the sandbox of the first one, but in new ipc namespaces
+		_, _, results := runCResult(CBaseDir, "64000", "1000", t, "-seccomp=none", "-batch="+batchDir, "-batch-output="+batchDir)
+		lines := strings.Split(strings.TrimSpace(results), "\n")
+		So(lines, ShouldHaveLength, 2)
+		for i := range lines {
+			So(lastResult(lines[i], t)["verdict"], ShouldEqual, "OK")
+			output, _ := ioutil.ReadFile(fmt.Sprintf("%s/%d.out", batchDir, i+1))
+			So(string(output), ShouldEqual, "created")
+		}
+	})
+}
+
+func TestC0048PoolMemoryPeak(t *testing.T) {
+	name := "child_allocation.c"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		copyCSourceFile(name, t)
+		batchDir := CProjectDir + "/tmp_batch"
+		defer func() {
+			for _, dir := range []string{CBaseDir, batchDir} {
+				if err := os.RemoveAll(dir); err != nil {
+					t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", dir, err)
+					t.FailNow()
+				}
+			}
+		}()
+		writeBatchInputs(batchDir, map[string]string{"1.in": "", "2.in": ""}, t)
+
+		So(compileC(name, CBaseDir, t), ShouldBeEmpty)
+		// the peak of the cgroup is reset between the cases rather than replaced by the rusage of the main process
+		_, _, results := runCResult(CBaseDir, "64000", "1000", t, "-seccomp=none", "-batch="+batchDir, "-batch-output="+batchDir)
+		lines := strings.Split(strings.TrimSpace(results), "\n")
+		So(lines, ShouldHaveLength, 2)
+		for i := range lines {
+			result := lastResult(lines[i], t)
+			So(result["verdict"], ShouldEqual, "OK")
+			So(result["memory"], ShouldBeGreaterThanOrEqualTo, 16*1024)
+		}
+	})
+}
+
+func TestC0049Swap(t *testing.T) {
+	name := "ac.c"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		copyCSourceFile(name, t)
+		batchDir := CProjectDir + "/tmp_batch"
+		defer func() {
+			for _, dir := range []string{CBaseDir, batchDir} {
+				if err := os.RemoveAll(dir); err != nil {
+					t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", dir, err)
+					t.FailNow()
+				}
+			}
+		}()
+		writeBatchInputs(batchDir, map[string]string{"1.in": "10:10:23AM", "2.in": "07:05:45PM"}, t)
+
+		So(compileC(name, CBaseDir, t), ShouldBeEmpty)
+		// swap is unlimited unless -swap is set, the sandbox lifts the limit of a case again before the next one
+		_, _, results := runCResult(CBaseDir, "64000", "1000", t, "-swap=0", "-batch="+batchDir, "-batch-output="+batchDir)
+		lines := strings.Split(strings.TrimSpace(results), "\n")
+		So(lines, ShouldHaveLength, 2)
+		for i := range lines {
+			So(lastResult(lines[i], t)["verdict"], ShouldEqual, "OK")
+		}
+		_, stderr := runC(CBaseDir, "64000", "1000", t, "-swap=-2")
+		So(stderr, ShouldContainSubstring, "invalid swap limit")
+	})
+}
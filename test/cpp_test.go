@@ -55,7 +55,7 @@ func compileCPP(name, baseDir string, t *testing.T) string {
 }
 
 // run binary in our container
-func runCPP(baseDir, memory, timeout string, t *testing.T) (string, string) {
+func runCPP(baseDir, memory, timeout string, t *testing.T, extraArgs ...string) (string, string) {
 	t.Log("Running file /Main ...")
 
 	var stdout, stderr bytes.Buffer
@@ -66,6 +66,7 @@ func runCPP(baseDir, memory, timeout string, t *testing.T) (string, string) {
 		"-command=./Main",
 		"-username=oj-user",
 	}
+	args = append(args, extraArgs...)
 	cmd := exec.Command("/opt/justice-sandbox/bin/clike_container", args...)
 	cmd.Stdin = strings.NewReader("10:10:23AM")
 	cmd.Stdout = &stdout
@@ -111,7 +112,7 @@ func TestCPP0002CompilerBomb1(t *testing.T) {
 			}
 		}()
 
-		So(compileCPP(name, CPPBaseDir, t), ShouldContainSubstring, "signal: killed")
+		So(compileCPP(name, CPPBaseDir, t), ShouldStartWith, "Compile Limit Exceeded")
 	})
 }
 
@@ -156,7 +157,7 @@ func TestCPP0005CompilerBomb4(t *testing.T) {
 			}
 		}()
 
-		So(compileCPP(name, CPPBaseDir, t), ShouldContainSubstring, "signal: killed")
+		So(compileCPP(name, CPPBaseDir, t), ShouldStartWith, "Compile Limit Exceeded")
 	})
 }
 
@@ -190,7 +191,7 @@ func TestCPP0007ForkBomb(t *testing.T) {
 		}()
 
 		So(compileCPP(name, CPPBaseDir, t), ShouldBeEmpty)
-		_, stderr := runCPP(CPPBaseDir, "64000", "1000", t)
+		_, stderr := runCPP(CPPBaseDir, "64000", "1000", t, "-seccomp=none")
 		So(stderr, ShouldContainSubstring, "Time Limit Error")
 	})
 }
@@ -271,7 +272,7 @@ func TestCPP0012RunCommandLine0(t *testing.T) {
 		}()
 
 		So(compileCPP(name, CPPBaseDir, t), ShouldBeEmpty)
-		stdout, _ := runCPP(CPPBaseDir, "16000", "1000", t)
+		stdout, _ := runCPP(CPPBaseDir, "16000", "1000", t, "-seccomp=none")
 		So(stdout, ShouldEqual, "32512 32512")
 	})
 }
@@ -292,3 +293,38 @@ func TestCPP0013RunWriteFile(t *testing.T) {
 		So(stdout, ShouldEqual, "write to file /test.txt failed\n")
 	})
 }
+
+func TestCPP0014ThreadLimits(t *testing.T) {
+	name := "thread.cpp"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		copyCPPSourceFile(name, t)
+		defer func() {
+			if err := os.RemoveAll(CPPBaseDir); err != nil {
+				t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", CPPBaseDir, err)
+				t.FailNow()
+			}
+		}()
+
+		So(compileCPP(name, CPPBaseDir, t), ShouldBeEmpty)
+		stdout, _ := runCPP(CPPBaseDir, "64000", "1000", t, "-pids=128", "-cpu-quota=-1")
+		So(stdout, ShouldEqual, "invoke in threads.\n")
+	})
+}
+
+func TestCPP0015InvalidLimits(t *testing.T) {
+	name := "ac.cpp"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		copyCPPSourceFile(name, t)
+		defer func() {
+			if err := os.RemoveAll(CPPBaseDir); err != nil {
+				t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", CPPBaseDir, err)
+				t.FailNow()
+			}
+		}()
+
+		So(compileCPP(name, CPPBaseDir, t), ShouldBeEmpty)
+		stdout, stderr := runCPP(CPPBaseDir, "64000", "1000", t, "-cpu-period=10")
+		So(stdout, ShouldBeEmpty)
+		So(stderr, ShouldContainSubstring, "invalid cpu period")
+	})
+}
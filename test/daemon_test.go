@@ -0,0 +1,508 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ZiheLiu/sandbox/judge"
+	. "github.com/smartystreets/goconvey/convey"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	DaemonBaseDir    string
+	DaemonProjectDir string
+	DaemonSocket     string
+	DaemonGRPCSocket string
+)
+
+// wait until the daemon listens on socket
+func waitSocket(socket string) {
+	for i := 0; i < 100; i++ {
+		// the socket file shows up on bind, before the daemon listens on it
+		if conn, err := net.Dial("unix", socket); err == nil {
+			_ = conn.Close()
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// start the daemon listening on DaemonSocket with DaemonProjectDir as work root, and a client talking to it
+func startDaemon(t *testing.T, extraArgs ...string) (*exec.Cmd, *http.Client) {
+	t.Log("Starting daemon ...")
+
+	// a socket left behind by the previous daemon must not be taken for the new one
+	_ = os.Remove(DaemonSocket)
+	_ = os.Remove(DaemonGRPCSocket)
+	args := append([]string{
+		"-socket=" + DaemonSocket, "-workers=2", "-work-root=" + DaemonProjectDir, "-username=oj-user",
+	}, extraArgs...)
+	cmd := exec.Command("/opt/justice-sandbox/bin/clike_daemon", args...)
+	if err := cmd.Start(); err != nil {
+		t.Errorf("Invoke `/opt/justice-sandbox/bin/clike_daemon` err: %v", err)
+		t.FailNow()
+	}
+	waitSocket(DaemonSocket)
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", DaemonSocket)
+			},
+		},
+	}
+	return cmd, client
+}
+
+// stop the daemon gracefully, it must not take longer than its shutdown grace period
+func stopDaemon(cmd *exec.Cmd, t *testing.T) time.Duration {
+	start := time.Now()
+	_ = cmd.Process.Signal(syscall.SIGTERM)
+	if err := cmd.Wait(); err != nil {
+		t.Errorf("Invoke `cmd.Wait()` of daemon err: %v", err)
+	}
+	return time.Since(start)
+}
+
+// submit a job and return its id
+func submitJob(client *http.Client, endpoint string, request map[string]interface{}, t *testing.T) string {
+	body, _ := json.Marshal(request)
+	resp, err := client.Post("http://daemon"+endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Errorf("Invoke `POST %s` err: %v", endpoint, err)
+		t.FailNow()
+	}
+	defer resp.Body.Close()
+
+	job := make(map[string]interface{})
+	_ = json.NewDecoder(resp.Body).Decode(&job)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Errorf("Invoke `POST %s` status: %d, %v", endpoint, resp.StatusCode, job)
+		t.FailNow()
+	}
+	return job["id"].(string)
+}
+
+// poll the job until it is done
+func waitJob(client *http.Client, id string, t *testing.T) map[string]interface{} {
+	for {
+		resp, err := client.Get("http://daemon/results/" + id)
+		if err != nil {
+			t.Errorf("Invoke `GET /results/%s` err: %v", id, err)
+			t.FailNow()
+		}
+		job := make(map[string]interface{})
+		_ = json.NewDecoder(resp.Body).Decode(&job)
+		_ = resp.Body.Close()
+		if job["status"] == "done" {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDaemon0000Fixture(t *testing.T) {
+	DaemonProjectDir, _ = os.Getwd()
+	DaemonBaseDir = DaemonProjectDir + "/tmp"
+	DaemonSocket = DaemonProjectDir + "/daemon.sock"
+	DaemonGRPCSocket = DaemonProjectDir + "/daemon_grpc.sock"
+}
+
+func TestDaemon0001CompileAndRun(t *testing.T) {
+	name := "ac.c"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		if err := os.MkdirAll(DaemonBaseDir, os.ModePerm); err != nil {
+			t.Errorf("Invoke mkdir(%s) err: %v", DaemonBaseDir, err.Error())
+		}
+		if err := exec.Command("cp", DaemonProjectDir+"/resources/c/"+name, DaemonBaseDir+"/Main.c").Run(); err != nil {
+			t.Errorf("Invoke `cp %s` err: %v", name, err)
+		}
+		_ = ioutil.WriteFile(DaemonProjectDir+"/daemon.in", []byte("10:10:23AM"), 0644)
+		cmd, client := startDaemon(t)
+		defer func() {
+			stopDaemon(cmd, t)
+			for _, name := range []string{DaemonBaseDir, DaemonProjectDir + "/daemon.in", DaemonProjectDir + "/daemon.out"} {
+				if err := os.RemoveAll(name); err != nil {
+					t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", name, err)
+					t.FailNow()
+				}
+			}
+		}()
+
+		compiled := waitJob(client, submitJob(client, "/compile", map[string]interface{}{
+			"basedir": DaemonBaseDir, "filename": "Main.c", "std": "gnu11", "timeout": 3000,
+		}, t), t)
+		So(compiled["error"], ShouldBeNil)
+
+		id := submitJob(client, "/run", map[string]interface{}{
+			"basedir": DaemonBaseDir,
+			"limits":  map[string]interface{}{"memory": 64000},
+			"stdin":   DaemonProjectDir + "/daemon.in",
+			"stdout":  "daemon.out",
+		}, t)
+		result := waitJob(client, id, t)["result"].(map[string]interface{})
+		So(result["verdict"], ShouldEqual, "OK")
+		stdout, _ := ioutil.ReadFile(DaemonProjectDir + "/daemon.out")
+		So(string(stdout), ShouldEqual, "10:10:23")
+	})
+}
+
+func TestDaemon0002GracefulShutdown(t *testing.T) {
+	name := "infinite_loop.c"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		if err := os.MkdirAll(DaemonBaseDir, os.ModePerm); err != nil {
+			t.Errorf("Invoke mkdir(%s) err: %v", DaemonBaseDir, err.Error())
+		}
+		if err := exec.Command("cp", DaemonProjectDir+"/resources/c/"+name, DaemonBaseDir+"/Main.c").Run(); err != nil {
+			t.Errorf("Invoke `cp %s` err: %v", name, err)
+		}
+		cmd, client := startDaemon(t)
+		defer func() {
+			if err := os.RemoveAll(DaemonBaseDir); err != nil {
+				t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", DaemonBaseDir, err)
+				t.FailNow()
+			}
+		}()
+
+		compiled := waitJob(client, submitJob(client, "/compile", map[string]interface{}{
+			"basedir": DaemonBaseDir, "filename": "Main.c", "std": "gnu11", "timeout": 3000,
+		}, t), t)
+		So(compiled["error"], ShouldBeNil)
+
+		submitJob(client, "/run", map[string]interface{}{
+			"basedir": DaemonBaseDir, "timeout": 30000, "limits": map[string]interface{}{"memory": 64000},
+		}, t)
+		time.Sleep(500 * time.Millisecond)
+		So(stopDaemon(cmd, t), ShouldBeLessThan, 5*time.Second)
+	})
+}
+
+func TestDaemon0003GRPCCompileAndRun(t *testing.T) {
+	name := "ac.c"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		if err := os.MkdirAll(DaemonBaseDir, os.ModePerm); err != nil {
+			t.Errorf("Invoke mkdir(%s) err: %v", DaemonBaseDir, err.Error())
+		}
+		if err := exec.Command("cp", DaemonProjectDir+"/resources/c/"+name, DaemonBaseDir+"/Main.c").Run(); err != nil {
+			t.Errorf("Invoke `cp %s` err: %v", name, err)
+		}
+		cmd, _ := startDaemon(t, "-grpc-socket="+DaemonGRPCSocket)
+		waitSocket(DaemonGRPCSocket)
+		conn, err := grpc.Dial("daemon", grpc.WithInsecure(), grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", DaemonGRPCSocket)
+		}))
+		if err != nil {
+			t.Errorf("Invoke `grpc.Dial(%s)` err: %v", DaemonGRPCSocket, err)
+			t.FailNow()
+		}
+		defer func() {
+			_ = conn.Close()
+			stopDaemon(cmd, t)
+			if err := os.RemoveAll(DaemonBaseDir); err != nil {
+				t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", DaemonBaseDir, err)
+				t.FailNow()
+			}
+		}()
+
+		stream, err := judge.NewJudgeServiceClient(conn).CompileAndRun(context.Background(), &judge.CompileAndRunRequest{
+			Compile: &judge.CompileRequest{Basedir: DaemonBaseDir, Std: "gnu11", Timeout: 3000},
+			Limits:  &judge.Limits{Memory: 64000, Seccomp: "c"},
+			TestCases: []*judge.TestCase{
+				{Name: "1", Input: []byte("10:10:23AM"), Expected: []byte("10:10:23")},
+				{Name: "2", Input: []byte("10:10:23PM"), Expected: []byte("10:10:23")},
+			},
+			Check: &judge.Check{Mode: "tokens"},
+		})
+		So(err, ShouldBeNil)
+
+		var events []*judge.Progress
+		for {
+			event, err := stream.Recv()
+			if err != nil {
+				So(err, ShouldEqual, io.EOF)
+				break
+			}
+			events = append(events, event)
+		}
+		So(events, ShouldHaveLength, 3)
+		So(events[0].GetCompile().Ok, ShouldBeTrue)
+		So(events[1].GetResult().Case, ShouldEqual, "1")
+		So(events[1].GetResult().Verdict, ShouldEqual, judge.Verdict_AC)
+		So(events[2].GetResult().Verdict, ShouldEqual, judge.Verdict_WA)
+		So(events[2].GetResult().Difference.Actual, ShouldEqual, "22:10:23")
+
+		// the daemon runs without -allow-seccomp-none and -seccomp-root
+		for _, seccomp := range []string{"none", "/etc/shadow"} {
+			_, err = judge.NewJudgeServiceClient(conn).Run(context.Background(), &judge.RunRequest{
+				Basedir: DaemonBaseDir,
+				Limits:  &judge.Limits{Memory: 64000, Seccomp: seccomp},
+			})
+			So(status.Code(err), ShouldEqual, codes.InvalidArgument)
+		}
+
+		// the limits of the compiler mirror the flags of clike_compiler
+		compiled, err := judge.NewJudgeServiceClient(conn).Compile(context.Background(), &judge.CompileRequest{
+			Basedir: DaemonBaseDir, Std: "gnu11", Timeout: 3000, FileSize: 512,
+		})
+		So(err, ShouldBeNil)
+		So(compiled.Verdict, ShouldEqual, judge.Verdict_CLE)
+		So(compiled.Limit, ShouldEqual, "fileSize")
+	})
+}
+
+func TestDaemon0004Pool(t *testing.T) {
+	name := "ac.c"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		if err := os.MkdirAll(DaemonBaseDir, os.ModePerm); err != nil {
+			t.Errorf("Invoke mkdir(%s) err: %v", DaemonBaseDir, err.Error())
+		}
+		if err := exec.Command("cp", DaemonProjectDir+"/resources/c/"+name, DaemonBaseDir+"/Main.c").Run(); err != nil {
+			t.Errorf("Invoke `cp %s` err: %v", name, err)
+		}
+		_ = ioutil.WriteFile(DaemonProjectDir+"/daemon.in", []byte("10:10:23AM"), 0644)
+		cmd, client := startDaemon(t, "-pool=1")
+		defer func() {
+			stopDaemon(cmd, t)
+			for _, name := range []string{DaemonBaseDir, DaemonProjectDir + "/daemon.in", DaemonProjectDir + "/daemon.out"} {
+				if err := os.RemoveAll(name); err != nil {
+					t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", name, err)
+					t.FailNow()
+				}
+			}
+		}()
+
+		compiled := waitJob(client, submitJob(client, "/compile", map[string]interface{}{
+			"basedir": DaemonBaseDir, "filename": "Main.c", "std": "gnu11", "timeout": 3000,
+		}, t), t)
+		So(compiled["error"], ShouldBeNil)
+
+		// the second run leases the sandbox reset after the first one
+		for i := 0; i < 2; i++ {
+			id := submitJob(client, "/run", map[string]interface{}{
+				"basedir": DaemonBaseDir,
+				"limits":  map[string]interface{}{"memory": 64000},
+				"stdin":   DaemonProjectDir + "/daemon.in",
+				"stdout":  DaemonProjectDir + "/daemon.out",
+			}, t)
+			result := waitJob(client, id, t)["result"].(map[string]interface{})
+			So(result["verdict"], ShouldEqual, "OK")
+			So(result["memory"], ShouldBeGreaterThan, 0)
+			stdout, _ := ioutil.ReadFile(DaemonProjectDir + "/daemon.out")
+			So(string(stdout), ShouldEqual, "10:10:23")
+		}
+	})
+}
+
+func TestDaemon0005WorkRoot(t *testing.T) {
+	name := "work root"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		// the daemon runs as root, its requests must neither pick the user nor reach files outside of the work root
+		out, err := exec.Command("/opt/justice-sandbox/bin/clike_daemon",
+			"-socket="+DaemonSocket, "-work-root="+DaemonProjectDir, "-username=root").CombinedOutput()
+		So(err, ShouldNotBeNil)
+		So(string(out), ShouldContainSubstring, "must not be root")
+
+		cmd, client := startDaemon(t)
+		defer stopDaemon(cmd, t)
+		for _, request := range []struct {
+			endpoint string
+			body     map[string]interface{}
+			error    string
+		}{
+			{"/run", map[string]interface{}{"basedir": "/etc"}, "must be below"},
+			{"/run", map[string]interface{}{"stdin": "/etc/shadow"}, "must be below"},
+			{"/run", map[string]interface{}{"stdout": "../daemon.out"}, "must be below"},
+			{"/run", map[string]interface{}{"username": "root"}, "unknown field"},
+			{"/run", map[string]interface{}{"seccomp": "none"}, "not allowed"},
+			{"/run", map[string]interface{}{"seccomp": "/etc/shadow"}, "only built-in profiles"},
+			{"/compile", map[string]interface{}{"basedir": "../"}, "must be below"},
+			{"/compile", map[string]interface{}{"compiler": "/bin/sh"}, "unknown field"},
+			{"/compile", map[string]interface{}{"username": "root"}, "unknown field"},
+		} {
+			body, _ := json.Marshal(request.body)
+			resp, err := client.Post("http://daemon"+request.endpoint, "application/json", bytes.NewReader(body))
+			So(err, ShouldBeNil)
+			reply := make(map[string]interface{})
+			_ = json.NewDecoder(resp.Body).Decode(&reply)
+			_ = resp.Body.Close()
+			So(resp.StatusCode, ShouldEqual, http.StatusBadRequest)
+			So(reply["error"], ShouldContainSubstring, request.error)
+		}
+
+		// the files of the problems are checked by the compilation, the daemon has no -problem-root
+		compiled := waitJob(client, submitJob(client, "/compile", map[string]interface{}{
+			"link": []string{"/etc/shadow"},
+		}, t), t)
+		So(compiled["error"], ShouldContainSubstring, "invalid link")
+		compiled = waitJob(client, submitJob(client, "/compile", map[string]interface{}{
+			"filename": "../../../etc/shadow",
+		}, t), t)
+		So(compiled["error"], ShouldContainSubstring, "invalid source")
+	})
+}
+
+func TestDaemon0006GRPCOutputLimit(t *testing.T) {
+	name := "output_flood.c"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		if err := os.MkdirAll(DaemonBaseDir, os.ModePerm); err != nil {
+			t.Errorf("Invoke mkdir(%s) err: %v", DaemonBaseDir, err.Error())
+		}
+		if err := exec.Command("cp", DaemonProjectDir+"/resources/c/"+name, DaemonBaseDir+"/Main.c").Run(); err != nil {
+			t.Errorf("Invoke `cp %s` err: %v", name, err)
+		}
+		cmd, _ := startDaemon(t, "-grpc-socket="+DaemonGRPCSocket)
+		waitSocket(DaemonGRPCSocket)
+		conn, err := grpc.Dial("daemon", grpc.WithInsecure(), grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", DaemonGRPCSocket)
+		}))
+		if err != nil {
+			t.Errorf("Invoke `grpc.Dial(%s)` err: %v", DaemonGRPCSocket, err)
+			t.FailNow()
+		}
+		defer func() {
+			_ = conn.Close()
+			stopDaemon(cmd, t)
+			if err := os.RemoveAll(DaemonBaseDir); err != nil {
+				t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", DaemonBaseDir, err)
+				t.FailNow()
+			}
+		}()
+
+		client := judge.NewJudgeServiceClient(conn)
+		compiled, err := client.Compile(context.Background(), &judge.CompileRequest{Basedir: DaemonBaseDir, Std: "gnu11", Timeout: 3000})
+		So(err, ShouldBeNil)
+		So(compiled.Ok, ShouldBeTrue)
+
+		// stdout is buffered by the daemon, the default limit stops the flood long before the timeout
+		response, err := client.Run(context.Background(), &judge.RunRequest{
+			Basedir: DaemonBaseDir,
+			Limits:  &judge.Limits{Timeout: 10000, Memory: 64000},
+		})
+		So(err, ShouldBeNil)
+		So(response.Result.Verdict, ShouldEqual, judge.Verdict_OLE)
+		So(len(response.Stdout), ShouldBeLessThanOrEqualTo, 1024*1024)
+	})
+}
+
+func TestDaemon0007PoolReset(t *testing.T) {
+	name := "pool_reset.c"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		if err := os.MkdirAll(DaemonBaseDir, os.ModePerm); err != nil {
+			t.Errorf("Invoke mkdir(%s) err: %v", DaemonBaseDir, err.Error())
+		}
+		for source, target := range map[string]string{"c/" + name: "Main.c", "python/sleep.py": "Main.py"} {
+			if err := exec.Command("cp", DaemonProjectDir+"/resources/"+source, DaemonBaseDir+"/"+target).Run(); err != nil {
+				t.Errorf("Invoke `cp %s` err: %v", source, err)
+			}
+		}
+		// the root filesystems of the sandboxes of other tests
+		stale := make(map[string]bool)
+		rootfs, _ := filepath.Glob(filepath.Join(os.TempDir(), "justice-pool-*"))
+		for _, dir := range rootfs {
+			stale[dir] = true
+		}
+		// a single sandbox, so every run leases the one the run before it returned
+		cmd, client := startDaemon(t, "-pool=1", "-allow-seccomp-none")
+		defer func() {
+			stopDaemon(cmd, t)
+			for _, name := range []string{DaemonBaseDir, DaemonProjectDir + "/daemon.out"} {
+				if err := os.RemoveAll(name); err != nil {
+					t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", name, err)
+					t.FailNow()
+				}
+			}
+		}()
+
+		compiled := waitJob(client, submitJob(client, "/compile", map[string]interface{}{
+			"basedir": DaemonBaseDir, "filename": "Main.c", "std": "gnu11", "timeout": 3000,
+		}, t), t)
+		So(compiled["error"], ShouldBeNil)
+
+		run := func(arg string, memory int) (map[string]interface{}, string) {
+			id := submitJob(client, "/run", map[string]interface{}{
+				"basedir": DaemonBaseDir,
+				"args":    []string{arg},
+				"seccomp": "none",
+				"timeout": 5000,
+				"limits":  map[string]interface{}{"memory": memory, "cpuQuota": -1},
+				"stdout":  DaemonProjectDir + "/daemon.out",
+			}, t)
+			result := waitJob(client, id, t)["result"].(map[string]interface{})
+			stdout, _ := ioutil.ReadFile(DaemonProjectDir + "/daemon.out")
+			return result, string(stdout)
+		}
+
+		// a process escaping into the background is killed
+		result, _ := run("background", 64000)
+		So(result["verdict"], ShouldEqual, "OK")
+		result, stdout := run("check", 64000)
+		So(result["verdict"], ShouldEqual, "OK")
+		So(stdout, ShouldEqual, "clean")
+
+		// the cpu time and the OOM kills of a run are not accounted to the next one
+		result, _ = run("spin", 64000)
+		So(result["verdict"], ShouldEqual, "OK")
+		So(result["cpuTime"], ShouldBeGreaterThanOrEqualTo, 100)
+		result, _ = run("check", 64000)
+		So(result["cpuTime"], ShouldBeLessThan, 100)
+		result, _ = run("oom", 32000)
+		So(result["verdict"], ShouldEqual, "MLE")
+		result, _ = run("check", 32000)
+		So(result["verdict"], ShouldEqual, "OK")
+		So(result["oomKilled"], ShouldBeFalse)
+
+		// what is written into the root filesystem of a run is wiped once it returns
+		id := submitJob(client, "/run", map[string]interface{}{
+			"basedir": DaemonBaseDir, "lang": "python", "timeout": 5000, "limits": map[string]interface{}{"memory": 64000},
+		}, t)
+		var leftover string
+		for deadline := time.Now().Add(5 * time.Second); leftover == "" && time.Now().Before(deadline); {
+			rootfs, _ = filepath.Glob(filepath.Join(os.TempDir(), "justice-pool-*"))
+			for _, dir := range rootfs {
+				if !stale[dir] {
+					leftover = filepath.Join(dir, "leftover")
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		So(leftover, ShouldNotBeEmpty)
+		So(ioutil.WriteFile(leftover, []byte("secret"), 0644), ShouldBeNil)
+		So(waitJob(client, id, t)["result"].(map[string]interface{})["verdict"], ShouldEqual, "OK")
+		_, err := os.Stat(leftover)
+		So(os.IsNotExist(err), ShouldBeTrue)
+
+		// a sandbox whose init died while idle is replaced by the next lease
+		procs, _ := filepath.Glob("/sys/fs/cgroup/pids/pool-*/cgroup.procs")
+		procsV2, _ := filepath.Glob("/sys/fs/cgroup/justice/pool-*/cgroup.procs")
+		killed := 0
+		for _, file := range append(procs, procsV2...) {
+			c, _ := ioutil.ReadFile(file)
+			for _, pid := range strings.Fields(string(c)) {
+				if p, err := strconv.Atoi(pid); err == nil && syscall.Kill(p, syscall.SIGKILL) == nil {
+					killed++
+				}
+			}
+		}
+		So(killed, ShouldEqual, 1)
+		result, stdout = run("check", 64000)
+		So(result["verdict"], ShouldEqual, "OK")
+		So(stdout, ShouldEqual, "clean")
+	})
+}
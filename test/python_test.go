@@ -0,0 +1,139 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var (
+	PythonBaseDir    string
+	PythonProjectDir string
+)
+
+// copy test source file `*.py` to tmp dir
+func copyPythonSourceFile(name string, t *testing.T) {
+	t.Logf("Copying file %s ...", name)
+	if err := os.MkdirAll(PythonBaseDir, os.ModePerm); err != nil {
+		t.Errorf("Invoke mkdir(%s) err: %v", PythonBaseDir, err.Error())
+	}
+
+	args := []string{
+		PythonProjectDir + "/resources/python/" + name,
+		PythonBaseDir + "/Main.py",
+	}
+	cmd := exec.Command("cp", args...)
+	if err := cmd.Run(); err != nil {
+		t.Errorf("Invoke `cp %s` err: %v", strings.Join(args, " "), err)
+	}
+}
+
+// run Main.py by the python profile in our container, and decode the json result
+func runPython(baseDir, memory, timeout string, t *testing.T) (string, map[string]interface{}) {
+	t.Log("Running Main.py ...")
+
+	var stdout, stderr bytes.Buffer
+	args := []string{
+		"-basedir=" + baseDir,
+		"-memory=" + memory,
+		"-timeout=" + timeout,
+		"-lang=python",
+		"-username=oj-user",
+		"-result=json",
+		"-result-fd=3",
+	}
+	records, resultWriter, err := os.Pipe()
+	if err != nil {
+		t.Errorf("Invoke `os.Pipe()` err: %v", err)
+		t.FailNow()
+	}
+	defer records.Close()
+	cmd := exec.Command("/opt/justice-sandbox/bin/clike_container", args...)
+	cmd.Stdin = strings.NewReader("10:10:23PM")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.ExtraFiles = []*os.File{resultWriter}
+	err = cmd.Start()
+	_ = resultWriter.Close()
+	if err != nil {
+		t.Errorf("Invoke `/opt/justice-sandbox/bin/clike_container %s` err: %v", strings.Join(args, " "), err)
+		t.FailNow()
+	}
+	record, _ := ioutil.ReadAll(records)
+	if err := cmd.Wait(); err != nil {
+		t.Errorf("Invoke `/opt/justice-sandbox/bin/clike_container %s` err: %v", strings.Join(args, " "), err)
+	}
+
+	t.Logf("stderr of runPython: %s", stderr.String())
+	result := make(map[string]interface{})
+	if err := json.Unmarshal(record, &result); err != nil {
+		t.Errorf("Invoke `json.Unmarshal(%s)` err: %v", record, err)
+	}
+	return stdout.String(), result
+}
+
+func TestPython0000Fixture(t *testing.T) {
+	PythonProjectDir, _ = os.Getwd()
+	PythonBaseDir = PythonProjectDir + "/tmp"
+}
+
+func TestPython0001AC(t *testing.T) {
+	name := "ac.py"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		copyPythonSourceFile(name, t)
+		defer func() {
+			if err := os.RemoveAll(PythonBaseDir); err != nil {
+				t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", PythonBaseDir, err)
+				t.FailNow()
+			}
+		}()
+
+		stdout, result := runPython(PythonBaseDir, "64000", "1000", t)
+		So(result["verdict"], ShouldEqual, "OK")
+		So(stdout, ShouldEqual, "22:10:23")
+		// the memory multiplier of the python profile
+		So(result["memoryLimit"], ShouldEqual, 128000)
+	})
+}
+
+func TestPython0002ReadHostFile(t *testing.T) {
+	name := "read_host_file.py"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		copyPythonSourceFile(name, t)
+		defer func() {
+			if err := os.RemoveAll(PythonBaseDir); err != nil {
+				t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", PythonBaseDir, err)
+				t.FailNow()
+			}
+		}()
+
+		stdout, result := runPython(PythonBaseDir, "64000", "1000", t)
+		So(result["verdict"], ShouldEqual, "RE")
+		So(stdout, ShouldBeEmpty)
+	})
+}
+
+func TestPython0003InfiniteLoop(t *testing.T) {
+	name := "infinite_loop.py"
+	Convey(fmt.Sprintf("Testing [%s]...", name), t, func() {
+		copyPythonSourceFile(name, t)
+		defer func() {
+			if err := os.RemoveAll(PythonBaseDir); err != nil {
+				t.Errorf("Invoke `os.RemoveAll(%s)` err: %v", PythonBaseDir, err)
+				t.FailNow()
+			}
+		}()
+
+		_, result := runPython(PythonBaseDir, "64000", "500", t)
+		So(result["verdict"], ShouldEqual, "TLE")
+		// the time multiplier of the python profile
+		So(result["wallTime"], ShouldBeGreaterThanOrEqualTo, 1500)
+	})
+}
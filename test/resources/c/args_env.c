@@ -0,0 +1,10 @@
+#include <stdio.h>
+#include <stdlib.h>
+
+int main(int argc, char *argv[]) {
+    for (int i = 1; i < argc; i++) {
+        printf("[%s]", argv[i]);
+    }
+    printf("\nTZ=%s LANG=%s PATH=%s\n", getenv("TZ"), getenv("LANG"), getenv("PATH") ? getenv("PATH") : "");
+    return 0;
+}
@@ -0,0 +1,25 @@
+#include <stdio.h>
+#include <string.h>
+
+// testlib style checker: ./checker input output answer, 0 for AC, 1 for WA and 2 for PE
+int main(int argc, char *argv[]) {
+    char output[64] = "", answer[64] = "";
+    FILE *out = fopen(argv[2], "r"), *ans = fopen(argv[3], "r");
+
+    if (out == NULL || ans == NULL) {
+        fprintf(stderr, "cannot open files");
+        return 3;
+    }
+    fscanf(ans, "%63s", answer);
+    if (fscanf(out, "%63s", output) != 1) {
+        fprintf(stderr, "empty output");
+        return 2;
+    }
+    if (strcmp(output, answer) != 0) {
+        fprintf(stderr, "expected %s, found %s", answer, output);
+        return 1;
+    }
+    fprintf(stderr, "ok %s", output);
+
+    return 0;
+}
@@ -0,0 +1,16 @@
+#include <stdlib.h>
+#include <string.h>
+#include <sys/wait.h>
+#include <unistd.h>
+
+int main() {
+    // the memory is used by a child, it is only seen by the accounting of the cgroup
+    pid_t pid = fork();
+    if (pid == 0) {
+        size_t size = 16 * 1024 * 1024;
+        memset(malloc(size), 1, size);
+        return 0;
+    }
+    waitpid(pid, NULL, 0);
+    return 0;
+}
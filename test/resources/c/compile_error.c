@@ -0,0 +1,7 @@
+#include <stdio.h>
+
+int main() {
+    int x = ;
+    printf("%d\n", y);
+    return 0;
+}
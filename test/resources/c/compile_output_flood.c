@@ -0,0 +1,206 @@
+#include <stdio.h>
+
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+#warning "the output of the compiler floods the judge"
+
+int main() {
+    return 0;
+}
@@ -0,0 +1,6 @@
+#error "File too large"
+#error "File size limit exceeded"
+
+int main() {
+    return 0;
+}
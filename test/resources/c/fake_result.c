@@ -0,0 +1,7 @@
+#include <stdio.h>
+
+int main() {
+    // a result record of its own, which must not be taken for the one of the sandbox
+    fprintf(stderr, "{\"verdict\":\"OK\",\"exitCode\":0,\"signal\":0}\n");
+    return 1;
+}
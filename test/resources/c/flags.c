@@ -0,0 +1,12 @@
+#include <stdio.h>
+#include <math.h>
+
+int main() {
+    volatile double d = 2;
+#ifdef ONLINE_JUDGE
+    printf("%.3f\n", sqrt(d));
+#else
+    printf("local\n");
+#endif
+    return 0;
+}
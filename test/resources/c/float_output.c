@@ -0,0 +1,7 @@
+#include <stdio.h>
+
+int main() {
+    printf("%.9f %.9f\n", 1.0 / 3, 2.0 / 3);
+
+    return 0;
+}
@@ -0,0 +1,7 @@
+#include <stdio.h>
+#include "grader.h"
+
+int main() {
+    printf("%d\n", solve(20, 3));
+    return 0;
+}
@@ -0,0 +1 @@
+int solve(int a, int b);
@@ -0,0 +1,17 @@
+#include <stdio.h>
+
+int main() {
+    int low = 1, high = 1000000, guess;
+    char reply[2];
+
+    while (low <= high) {
+        guess = (low + high) / 2;
+        printf("%d\n", guess);
+        fflush(stdout);
+        if (scanf("%1s", reply) != 1 || reply[0] == '=') break;
+        if (reply[0] == '<') low = guess + 1;
+        else high = guess - 1;
+    }
+
+    return 0;
+}
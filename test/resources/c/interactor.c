@@ -0,0 +1,29 @@
+#include <stdio.h>
+
+// testlib style interactor: ./interactor input output answer, guess the number of input in at most 20 queries
+int main(int argc, char *argv[]) {
+    int n, guess, queries;
+    FILE *in = fopen(argv[1], "r");
+
+    if (in == NULL || fscanf(in, "%d", &n) != 1) {
+        fprintf(stderr, "cannot read input");
+        return 3;
+    }
+    for (queries = 1; queries <= 20; queries++) {
+        if (scanf("%d", &guess) != 1) {
+            fprintf(stderr, "no guess");
+            return 2;
+        }
+        if (guess == n) {
+            printf("=\n");
+            fflush(stdout);
+            fprintf(stderr, "ok %d queries", queries);
+            return 0;
+        }
+        printf(guess < n ? "<\n" : ">\n");
+        fflush(stdout);
+    }
+    fprintf(stderr, "too many queries");
+
+    return 1;
+}
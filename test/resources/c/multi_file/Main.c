@@ -0,0 +1,7 @@
+#include <stdio.h>
+#include "grader.h"
+#include "util.h"
+
+int solve(int a, int b) {
+    return twice(a) + b;
+}
@@ -0,0 +1,7 @@
+#include <stdio.h>
+#include <math.h>
+
+int main() {
+    printf("%f\n", sqrt(2.0));
+    return 0;
+}
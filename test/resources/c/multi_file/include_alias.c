@@ -0,0 +1,6 @@
+#include <stdio.h>
+#include <x86_64-linux-gnu/sys/time.h>
+
+int main() {
+    return 0;
+}
@@ -0,0 +1,16 @@
+#include <stdio.h>
+#include "/usr/include/stdlib.h"
+#inc\
+lude /* comment */ "../../usr/include/string.h"
+#define HEADER <stdio.h>
+#include HEADER
+/* #include "/usr/include/ctype.h" */
+const char *s = "/*";
+#include <bits/stdc++.h>
+const char *t = "*/";
+%:include </usr/include/math.h>
+??=include "/usr/include/ctype.h"
+
+int main() {
+    return 0;
+}
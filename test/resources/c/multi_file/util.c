@@ -0,0 +1,5 @@
+#include "util.h"
+
+int twice(int x) {
+    return 2 * x;
+}
@@ -0,0 +1,8 @@
+#include <stdio.h>
+
+int main() {
+    while (1) {
+        puts("Hello, world!");
+    }
+    return 0;
+}
@@ -0,0 +1,43 @@
+#include <signal.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <unistd.h>
+
+// each run of a pooled sandbox does one thing, check finds out what the runs before it left behind
+int main(int argc, char *argv[]) {
+    if (argc < 2) {
+        return 2;
+    }
+    if (strcmp(argv[1], "background") == 0) {
+        if (fork() == 0) {
+            // a daemon, it holds none of the files of the run
+            setsid();
+            close(0);
+            close(1);
+            close(2);
+            while (1) {
+                pause();
+            }
+        }
+        return 0;
+    }
+    if (strcmp(argv[1], "spin") == 0) {
+        volatile unsigned long i;
+        for (i = 0; i < 300000000UL; i++) {
+        }
+        return 0;
+    }
+    if (strcmp(argv[1], "oom") == 0) {
+        while (1) {
+            memset(malloc(1024 * 1024), 1, 1024 * 1024);
+        }
+    }
+    // kill(-1) reaches every process of the sandbox but its init and the caller
+    if (kill(-1, 0) == 0) {
+        printf("leftover process");
+        return 1;
+    }
+    printf("clean");
+    return 0;
+}
@@ -0,0 +1,21 @@
+#include <stdio.h>
+#include <string.h>
+#include <sys/ipc.h>
+#include <sys/shm.h>
+
+int main() {
+    // a segment outlives its creator, the next run must not find the one left by this run
+    int id = shmget(0x6a75, 4096, 0);
+    if (id != -1) {
+        printf("leftover %s", (char *) shmat(id, NULL, SHM_RDONLY));
+        return 1;
+    }
+    id = shmget(0x6a75, 4096, IPC_CREAT | 0600);
+    if (id == -1) {
+        perror("shmget");
+        return 2;
+    }
+    strcpy((char *) shmat(id, NULL, 0), "secret");
+    printf("created");
+    return 0;
+}
@@ -0,0 +1,5 @@
+import re
+
+hh, mm, ss, tt = re.match(r"(\d+):(\d+):(\d+)(AM|PM)", input()).groups()
+hh = int(hh) % 12 + (12 if tt == "PM" else 0)
+print("%02d:%s:%s" % (hh, mm, ss), end="")
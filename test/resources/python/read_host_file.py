@@ -0,0 +1 @@
+print(open("/etc/passwd").read())
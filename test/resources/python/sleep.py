@@ -0,0 +1,4 @@
+import time
+
+# keeps the sandbox busy for a while
+time.sleep(1)